@@ -0,0 +1,144 @@
+package crawler
+
+import (
+	"context"
+	"net/url"
+
+	"codeflow.dananglin.me.uk/apollo/web-crawler/internal/util"
+)
+
+// checkCanonical records the canonical URL declared by the fetched page and groups
+// the page under it. The canonical URL is checked against the crawl's scope, the
+// robots.txt rules and the URL filter in the same way as a link found on the page.
+func (c *Crawler) checkCanonical(ctx context.Context, pageURL, pageKey string, current task, htmlDoc string) {
+	rawCanonicalURL, err := util.GetCanonicalURLFromHTML(htmlDoc, pageURL)
+	if err != nil {
+		c.pageError("unable to retrieve the canonical URL", pageURL, current.Depth, err)
+
+		return
+	}
+
+	if rawCanonicalURL == "" {
+		return
+	}
+
+	canonical, err := c.discover(ctx, rawCanonicalURL, current.Seed, current.Depth, false)
+	if err != nil {
+		c.pageError("unable to record the canonical URL", rawCanonicalURL, current.Depth, err)
+
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.groupUnderCanonical(pageKey, canonical, !sameHost(pageURL, rawCanonicalURL))
+}
+
+// groupUnderCanonical records the canonical URL of the variant page. If the canonical
+// URL is in the crawl's scope then the links to the variant are moved to the canonical
+// page which is scheduled for fetching like any other linked page. The canonical URL
+// is only recorded the first time the variant is fetched. The caller must hold the lock.
+func (c *Crawler) groupUnderCanonical(variantKey string, canonical discovery, otherHost bool) {
+	canonicalKey := canonical.task.NormalisedURL
+
+	variant, _ := c.pages.get(variantKey)
+	if variant.canonical != "" || canonicalKey == variantKey {
+		return
+	}
+
+	variant.canonical = canonicalKey
+	variant.canonicalOtherHost = otherHost
+	c.pages.put(variantKey, variant)
+
+	if !canonical.internal {
+		return
+	}
+
+	stat, exists := c.pages.get(canonicalKey)
+
+	if exists {
+		stat.depth = min(stat.depth, variant.depth)
+	} else {
+		stat.seed = canonical.task.Seed
+		stat.rawURL = canonical.task.RawURL
+		stat.internal = canonical.internal
+		stat.subdomain = canonical.subdomain
+		stat.scopeHost = canonical.scopeHost
+		stat.disallowed = canonical.disallowed
+		stat.excluded = canonical.excluded
+		stat.depth = variant.depth
+	}
+
+	schedule := c.fetchable(stat) && !stat.scheduled
+
+	if (!exists || schedule) && !c.admit(stat, exists) {
+		if !exists {
+			return
+		}
+
+		schedule = false
+	}
+
+	if schedule {
+		stat.scheduled = true
+
+		c.frontier.push(task{
+			RawURL:        stat.rawURL,
+			NormalisedURL: canonicalKey,
+			Depth:         stat.depth,
+			FromSitemap:   false,
+			Seed:          stat.seed,
+		})
+	}
+
+	stat.count += variant.count
+	stat.linked = stat.linked || variant.linked
+	stat.referrers = append(stat.referrers, variant.referrers...)
+	stat.variants = append(stat.variants, variantKey)
+	c.pages.put(canonicalKey, stat)
+
+	variant.count = 0
+	variant.referrers = nil
+	c.pages.put(variantKey, variant)
+}
+
+// canonicalDiscovery returns the discovery of the canonical URL if the discovered URL
+// is a variant that was grouped under its canonical URL. Otherwise the discovery
+// is returned unchanged. The caller must hold the lock.
+func (c *Crawler) canonicalDiscovery(found discovery) discovery {
+	variant, exists := c.pages.get(found.task.NormalisedURL)
+	if !exists || variant.canonical == "" {
+		return found
+	}
+
+	canonical, exists := c.pages.get(variant.canonical)
+	if !exists || !canonical.internal {
+		return found
+	}
+
+	found.task.RawURL = canonical.rawURL
+	found.task.NormalisedURL = variant.canonical
+	found.internal = canonical.internal
+	found.subdomain = canonical.subdomain
+	found.scopeHost = canonical.scopeHost
+	found.disallowed = canonical.disallowed
+	found.excluded = canonical.excluded
+
+	return found
+}
+
+// sameHost evaluates to true if both URLs have the same host.
+func sameHost(rawURL, otherRawURL string) bool {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+
+	otherParsedURL, err := url.Parse(otherRawURL)
+	if err != nil {
+		return false
+	}
+
+	return parsedURL.Hostname() == otherParsedURL.Hostname()
+}
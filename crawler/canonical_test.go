@@ -0,0 +1,93 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCanonical(t *testing.T) {
+	t.Parallel()
+
+	page := func(canonical, body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprintf(w, `<html><head><link rel="canonical" href="%s"></head><body>%s</body></html>`, canonical, body)
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", page(
+		"/",
+		`<a href="/post/print">Print</a><a href="/post/amp">AMP</a><a href="/syndicated">Syndicated</a><a href="/broken">Broken</a>`,
+	))
+	mux.HandleFunc("/post/print", page("/post", `<a href="/post/amp">AMP</a>`))
+	mux.HandleFunc("/post/amp", page("/post", ""))
+	mux.HandleFunc("/post", page("/post", ""))
+	mux.HandleFunc("/syndicated", page("https://elsewhere.example/post", ""))
+	mux.HandleFunc("/broken", page("/missing", ""))
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	testCrawler, err := New(
+		[]string{server.URL},
+		WithMaxWorkers(1),
+		WithMaxPages(20),
+		WithIgnoreRobots(true),
+		WithRetries(1, 0, 0),
+	)
+	if err != nil {
+		t.Fatalf("Test 'TestCanonical' FAILED: unexpected error creating the crawler: %v", err)
+	}
+
+	testCrawler.crawl(context.Background())
+
+	host := testCrawler.seeds[0].Hostname()
+
+	canonical := testCrawler.page(host + "/post")
+	wantVariants := []string{host + "/post/print", host + "/post/amp"}
+
+	// The canonical page is not linked directly but the three links to
+	// its variants are moved to it.
+	if canonical.count != 3 || canonical.attempts != 1 || !reflect.DeepEqual(canonical.variants, wantVariants) {
+		t.Errorf(
+			"Test 'TestCanonical' FAILED: unexpected canonical page: want 3 links, 1 attempt and variants %v, "+
+				"got %d links, %d attempts and variants %v",
+			wantVariants,
+			canonical.count,
+			canonical.attempts,
+			canonical.variants,
+		)
+	}
+
+	if variant := testCrawler.page(host + "/post/amp"); variant.count != 0 || variant.canonical != host+"/post" {
+		t.Errorf("Test 'TestCanonical' FAILED: unexpected variant page: got %+v", variant)
+	}
+
+	if self := testCrawler.page(host); self.canonical != "" {
+		t.Errorf("Test 'TestCanonical' FAILED: a self-referencing canonical URL was recorded: got %q", self.canonical)
+	}
+
+	var output strings.Builder
+
+	if err := testCrawler.WriteReport(&output, "", "text", false); err != nil {
+		t.Fatalf("Test 'TestCanonical' FAILED: unexpected error writing the report: %v", err)
+	}
+
+	textReport := output.String()
+
+	for _, want := range []string{
+		"    variant " + host + "/post/amp",
+		host + "/syndicated \u2192 elsewhere.example/post (points to a different host)",
+		host + "/broken \u2192 " + host + "/missing (points to a URL with status 404)",
+	} {
+		if !strings.Contains(textReport, want) {
+			t.Errorf("Test 'TestCanonical' FAILED: the report does not contain %q:\n%s", want, textReport)
+		}
+	}
+}
@@ -0,0 +1,1042 @@
+package crawler
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+
+	"codeflow.dananglin.me.uk/apollo/web-crawler/internal/filter"
+	"codeflow.dananglin.me.uk/apollo/web-crawler/internal/scope"
+	"codeflow.dananglin.me.uk/apollo/web-crawler/internal/util"
+)
+
+var (
+	errNoSeedURLs         = errors.New("no seed URLs are provided")
+	errResumeWithoutState = errors.New("the state file must be provided to resume a crawl")
+)
+
+type Crawler struct {
+	pages           pageStore
+	seeds           []*url.URL
+	scope           scope.Scope
+	mu              *sync.Mutex
+	frontier        *frontier
+	maxWorkers      int
+	externalWorkers int
+	maxPages        int
+	maxExternal     int
+	maxDuration     time.Duration
+	maxErrors       int
+	maxBytes        int64
+	maxDepth        int
+	maxRedirects    int
+	userAgent       string
+	ignoreRobots    bool
+	respectNofollow bool
+	filter          filter.Filter
+	robots          map[string]*robotsEntry
+	limiter         *hostLimiter
+	retryPolicy     retryPolicy
+	stateFile       string
+	checkpoint      time.Duration
+	resume          bool
+	sitemaps        bool
+	checkExternal   bool
+	partial         bool
+	logger          *slog.Logger
+	hooks           hookList
+	fetcher         Fetcher
+	storeFile       string
+
+	// scheduledPages is the number of internal pages pushed to the frontier
+	// and externalLinks is the number of unique external links recorded.
+	// fetchedPages and fetchErrors are the number of pages fetched and the
+	// number of those that failed, and fetchedBytes is the total size of the
+	// fetched pages. haltReason is the stop condition that halted the crawl
+	// and started and finished are the times at which the crawl was started
+	// and finished. All are only read and written under the lock.
+	scheduledPages int
+	externalLinks  int
+	fetchedPages   int
+	fetchErrors    int
+	fetchedBytes   int64
+	stopReason     StopReason
+	haltReason     StopReason
+	started        time.Time
+	finished       time.Time
+}
+
+// config is the configuration for the crawler. See the options for details.
+type config struct {
+	// MaxWorkers is the maximum number of concurrent workers.
+	MaxWorkers int
+
+	// ExternalWorkers is the maximum number of concurrent workers that check the
+	// external links.
+	ExternalWorkers int
+
+	// MaxPages is the maximum number of internal pages to fetch before stopping the crawl.
+	// A MaxPages of 0 or less means there is no limit.
+	MaxPages int
+
+	// MaxExternalLinks is the maximum number of unique external links to record before
+	// stopping the crawl. A MaxExternalLinks of 0 or less means there is no limit.
+	MaxExternalLinks int
+
+	// MaxDuration is the maximum duration of the crawl. A MaxDuration of 0 or less
+	// means there is no limit.
+	MaxDuration time.Duration
+
+	// MaxErrors is the maximum number of pages that fail to be fetched before the
+	// crawl is stopped. A MaxErrors of 0 or less means there is no limit.
+	MaxErrors int
+
+	// MaxBytes is the maximum total size of the fetched pages before the crawl is
+	// stopped. A MaxBytes of 0 or less means there is no limit.
+	MaxBytes int64
+
+	// MaxDepth is the maximum number of clicks away from the base URL that the crawler
+	// will follow links to. A MaxDepth of 0 or less means there is no depth limit.
+	MaxDepth int
+
+	// MaxRedirects is the maximum number of redirects followed when fetching a page.
+	// A page that redirects more times than this is reported as an error.
+	MaxRedirects int
+
+	// ScopeMode decides which hosts are crawled. The modes are "host" (the default),
+	// "subdomains", "domain" and "hosts". See the scope package for details.
+	ScopeMode string
+
+	// ScopeHosts is the list of hosts that are crawled in addition to the host of
+	// the base URL when the scope mode is "hosts".
+	ScopeHosts []string
+
+	// UserAgent is the user agent sent with every request. It is also used to
+	// select the rules that apply to the crawler from a site's robots.txt file.
+	UserAgent string
+
+	// IgnoreRobots disables the fetching and enforcement of robots.txt files.
+	IgnoreRobots bool
+
+	// RespectNofollow stops the crawler from following the links marked with rel="nofollow"
+	// and all the links on the pages with the nofollow directive in a robots meta tag or
+	// in the X-Robots-Tag header. The directives of every page are reported either way.
+	RespectNofollow bool
+
+	// Include is the list of patterns that internal URLs must match to be crawled.
+	// Every internal URL is crawled if this is empty.
+	Include []string
+
+	// Exclude is the list of patterns of the internal URLs that must not be crawled.
+	// See the filter package for the syntax of the patterns.
+	Exclude []string
+
+	// RequestsPerSecond is the maximum number of requests per second sent to each
+	// host. A value of 0 or less means there is no limit.
+	RequestsPerSecond float64
+
+	// MinDelay is the minimum delay between consecutive requests to the same host.
+	MinDelay time.Duration
+
+	// MaxAttempts is the maximum number of attempts made to retrieve a page when
+	// the request fails with a transient error such as a timeout, a connection reset,
+	// a 5xx status or the 429 status.
+	MaxAttempts int
+
+	// RetryBaseDelay is the delay before the first retry. The delay doubles after
+	// every failed attempt. A Retry-After header sent by the server takes precedence.
+	RetryBaseDelay time.Duration
+
+	// RetryMaxDelay is the maximum delay between two attempts. Pages are not retried
+	// if the server asks the crawler to wait for longer than this.
+	RetryMaxDelay time.Duration
+
+	// StateFile is the file where the state of the crawl is saved so that the
+	// crawl can be resumed later. The state is not saved if this is empty.
+	StateFile string
+
+	// CheckpointInterval is the interval between saves of the state of the crawl.
+	CheckpointInterval time.Duration
+
+	// Resume resumes the crawl from the state saved in the state file.
+	Resume bool
+
+	// Sitemaps crawls the pages listed in the sitemaps of the seed URLs' sites
+	// once the links from the seed URLs have been followed.
+	Sitemaps bool
+
+	// CheckExternalLinks checks the external links once the crawl has finished.
+	CheckExternalLinks bool
+
+	// Logger is the logger that the crawl events are logged to.
+	// Nothing is logged if this is nil.
+	Logger *slog.Logger
+
+	// Hooks are the hooks that observe the crawl.
+	Hooks []Hooks
+
+	// Fetcher sends the crawler's HTTP requests. An HTTPFetcher with the
+	// default HTTP client is used if this is nil.
+	Fetcher Fetcher
+
+	// StoreFile is the database file that the pages are stored in during
+	// the crawl. The pages are kept in memory if this is empty.
+	StoreFile string
+}
+
+type pageStat struct {
+	count      int
+	internal   bool
+	subdomain  bool
+	scopeHost  bool
+	depth      int
+	disallowed bool
+	excluded   bool
+	attempts   int
+	linked     bool
+	inSitemap  bool
+	scheduled  bool
+	referrers  []Referrer
+	rawURL     string
+	checked    bool
+	status     int
+	errorClass string
+
+	// The metadata of the response received when the page was fetched.
+	fetched     bool
+	contentType string
+	size        int64
+	latency     time.Duration
+	fetchError  string
+
+	// The redirect chain followed when the page was fetched, the final
+	// destination of the chain, whether the chain looped and the reason why
+	// the chain was stopped before its destination. redirectedFrom is the
+	// page whose request reached this page through a redirect, in which
+	// case this page was not fetched with a request of its own.
+	redirects       []RedirectHop
+	redirectsTo     string
+	redirectLoop    bool
+	redirectStopped string
+	redirectedFrom  string
+
+	// The canonical URL declared by the page and the variant URLs
+	// grouped under the page when it is the canonical URL of others.
+	canonical          string
+	canonicalOtherHost bool
+	variants           []string
+
+	// The seed URL from which the page was first found.
+	seed string
+
+	// The robots directives of the page and the number of links
+	// on the page that are marked with rel="nofollow". nofollowOnly is
+	// true while the page was only found through nofollow links that the
+	// crawler respects, in which case it is recorded but not fetched.
+	noIndex       bool
+	noFollow      bool
+	nofollowLinks int
+	nofollowOnly  bool
+}
+
+// Referrer is the source of a link to a page. Every link found on a page is
+// an edge of the crawl's link graph from the source page to the linked page.
+type Referrer struct {
+	Source     string `json:"source"`
+	AnchorText string `json:"anchorText"`
+}
+
+// New returns a new crawler for the websites at the seed URLs configured with the
+// given options. The host of every seed URL is in the crawl's scope.
+func New(rawSeedURLs []string, options ...Option) (*Crawler, error) {
+	config := defaultConfig()
+
+	for _, option := range slices.All(options) {
+		option(&config)
+	}
+
+	return newCrawler(rawSeedURLs, config)
+}
+
+// newCrawler returns a new crawler for the websites at the seed URLs.
+func newCrawler(rawSeedURLs []string, config config) (*Crawler, error) {
+	if len(rawSeedURLs) == 0 {
+		return nil, errNoSeedURLs
+	}
+
+	if config.Resume && config.StateFile == "" {
+		return nil, errResumeWithoutState
+	}
+
+	seeds := make([]*url.URL, 0, len(rawSeedURLs))
+
+	for _, rawSeedURL := range slices.All(rawSeedURLs) {
+		seedURL, err := url.Parse(rawSeedURL)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse the seed URL: %w", err)
+		}
+
+		if slices.ContainsFunc(seeds, func(seed *url.URL) bool { return seed.String() == seedURL.String() }) {
+			continue
+		}
+
+		seeds = append(seeds, seedURL)
+	}
+
+	linkScope, err := scope.New(scope.Mode(config.ScopeMode), seeds, config.ScopeHosts)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create the crawl scope: %w", err)
+	}
+
+	urlFilter, err := filter.New(config.Include, config.Exclude)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create the URL filter: %w", err)
+	}
+
+	logger := config.Logger
+	if logger == nil {
+		logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+
+	fetcher := config.Fetcher
+	if fetcher == nil {
+		fetcher = NewHTTPFetcher(nil)
+	}
+
+	crawler := Crawler{
+		pages:           newMemoryStore(),
+		seeds:           seeds,
+		scope:           linkScope,
+		mu:              &sync.Mutex{},
+		frontier:        newFrontier(),
+		maxWorkers:      max(config.MaxWorkers, 1),
+		externalWorkers: max(config.ExternalWorkers, 1),
+		maxPages:        config.MaxPages,
+		maxExternal:     config.MaxExternalLinks,
+		maxDuration:     config.MaxDuration,
+		maxErrors:       config.MaxErrors,
+		maxBytes:        config.MaxBytes,
+		maxDepth:        config.MaxDepth,
+		maxRedirects:    max(config.MaxRedirects, 0),
+		userAgent:       config.UserAgent,
+		ignoreRobots:    config.IgnoreRobots,
+		respectNofollow: config.RespectNofollow,
+		filter:          urlFilter,
+		robots:          make(map[string]*robotsEntry),
+		limiter:         newHostLimiter(config.RequestsPerSecond, config.MinDelay),
+		retryPolicy: retryPolicy{
+			maxAttempts: max(config.MaxAttempts, 1),
+			baseDelay:   config.RetryBaseDelay,
+			maxDelay:    config.RetryMaxDelay,
+		},
+		stateFile:     config.StateFile,
+		checkpoint:    config.CheckpointInterval,
+		resume:        config.Resume,
+		sitemaps:      config.Sitemaps,
+		checkExternal: config.CheckExternalLinks,
+		partial:       false,
+		logger:        logger,
+		hooks:         config.Hooks,
+		fetcher:       fetcher,
+		storeFile:     config.StoreFile,
+
+		scheduledPages: 0,
+		externalLinks:  0,
+		fetchedPages:   0,
+		fetchErrors:    0,
+		fetchedBytes:   0,
+		stopReason:     StopReasonCompleted,
+		haltReason:     "",
+		started:        time.Time{},
+		finished:       time.Time{},
+	}
+
+	return &crawler, nil
+}
+
+// Run crawls the websites from the seed URLs and blocks until the crawl has finished.
+// If the crawl is resumed then the state of the previous crawl is loaded from the
+// state file first. The pages in the sitemaps are crawled and the external links are
+// checked after the links from the seed URLs have been followed, if enabled. The crawl
+// stops early when the context is cancelled and the result is then marked as partial.
+// The result is also returned when the final state of the crawl could not be saved
+// or when the page store failed, in which case the result may be incomplete.
+// The result only has the records of the pages when they are kept in memory; the
+// report of a crawl kept in a store file is written from the store by WriteReport.
+// A crawler must only be run once and it must be closed once its report has been
+// written.
+func (c *Crawler) Run(ctx context.Context) (Result, error) {
+	if c.storeFile != "" {
+		store, err := openDiskStore(c.storeFile)
+		if err != nil {
+			return Result{}, fmt.Errorf("unable to open the page store: %w", err)
+		}
+
+		c.pages = store
+	}
+
+	// The state must be loaded before the checkpoints start so that the
+	// saved state is not overwritten.
+	if c.resume {
+		if err := c.loadState(); err != nil {
+			return Result{}, fmt.Errorf("unable to load the state of the previous crawl: %w", err)
+		}
+	}
+
+	c.mu.Lock()
+	c.started = time.Now()
+	c.mu.Unlock()
+
+	if c.maxDuration > 0 {
+		timer := time.AfterFunc(c.maxDuration, func() {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+
+			c.halt(StopReasonMaxDuration)
+		})
+		defer timer.Stop()
+	}
+
+	checkpointCtx, stopCheckpoints := context.WithCancel(context.Background())
+	checkpointsDone := make(chan struct{})
+
+	go func() {
+		defer close(checkpointsDone)
+
+		c.runCheckpoints(checkpointCtx, c.checkpoint)
+	}()
+
+	// A resumed crawl continues from the pages in its frontier and only adds
+	// the seed URLs that were not recorded before it was interrupted.
+	c.crawl(ctx)
+
+	// Crawl the pages listed in the sitemaps after the crawl of the seed URLs
+	// has finished so that the orphan pages can be identified.
+	// This is safe to repeat when a crawl is resumed as pages that were
+	// already crawled are not crawled again.
+	if c.sitemaps && ctx.Err() == nil && c.pages.err() == nil && !c.halted() {
+		c.crawlSitemaps(ctx)
+	}
+
+	// The external links are checked once all of them have been found.
+	if c.checkExternal && ctx.Err() == nil && c.pages.err() == nil && !c.halted() {
+		c.checkExternalLinks(ctx)
+	}
+
+	stopCheckpoints()
+	<-checkpointsDone
+
+	c.mu.Lock()
+	c.finished = time.Now()
+	c.mu.Unlock()
+
+	// Save the final state so that an interrupted crawl can be resumed.
+	if c.stateFile != "" {
+		if err := c.saveState(); err != nil {
+			return c.result(), fmt.Errorf("unable to save the state of the crawl: %w", err)
+		}
+	}
+
+	if err := c.pages.err(); err != nil {
+		return c.result(), fmt.Errorf("unable to store the pages: %w", err)
+	}
+
+	return c.result(), nil
+}
+
+// Close releases the page store of the crawler.
+func (c *Crawler) Close() error {
+	if err := c.pages.close(); err != nil {
+		return fmt.Errorf("unable to close the page store: %w", err)
+	}
+
+	return nil
+}
+
+// crawl crawls the websites starting from the seed URLs and blocks until the crawl
+// has finished. Pages are fetched by a fixed pool of workers in breadth-first order.
+// Crawling stops early when the context is cancelled; any pages that have not yet been
+// fetched are skipped and the crawl is marked as partial. The seed URLs that were
+// already recorded before a resumed crawl was interrupted are not added again.
+func (c *Crawler) crawl(ctx context.Context) {
+	for _, seedURL := range slices.All(c.seeds) {
+		if c.seedRecorded(seedURL.String()) {
+			continue
+		}
+
+		seed, err := c.discover(ctx, seedURL.String(), seedURL.String(), 0, false)
+
+		// The seed URL is not recorded as disallowed when the crawl is cancelled
+		// before its robots.txt rules are retrieved so that it is crawled when
+		// the crawl is resumed.
+		if ctx.Err() != nil {
+			c.markPartial()
+
+			return
+		}
+
+		if err != nil {
+			c.logger.Warn("unable to crawl the seed URL", slog.String("url", seedURL.String()), slog.Any("error", err))
+
+			continue
+		}
+
+		// The seed URLs are always crawled so that the pages matching the
+		// include patterns can be found from them.
+		seed.excluded = false
+
+		c.addPageVisit(seed)
+	}
+
+	c.run(ctx)
+}
+
+// seedRecorded evaluates to true if the seed URL was already recorded.
+func (c *Crawler) seedRecorded(rawURL string) bool {
+	normalisedURL, err := util.NormaliseURL(rawURL)
+	if err != nil {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	_, exists := c.pages.get(normalisedURL)
+
+	return exists
+}
+
+// seedURLs returns the seed URLs of the crawl.
+func (c *Crawler) seedURLs() []string {
+	seeds := make([]string, len(c.seeds))
+
+	for ind := range slices.All(c.seeds) {
+		seeds[ind] = c.seeds[ind].String()
+	}
+
+	return seeds
+}
+
+// run starts the workers and blocks until the frontier is exhausted or
+// the context is cancelled.
+func (c *Crawler) run(ctx context.Context) {
+	var waitGroup sync.WaitGroup
+
+	for range c.maxWorkers {
+		waitGroup.Add(1)
+
+		go func() {
+			defer waitGroup.Done()
+
+			c.worker(ctx)
+		}()
+	}
+
+	waitGroup.Wait()
+
+	if (ctx.Err() != nil || c.pages.err() != nil || c.halted()) && c.frontier.pending() > 0 {
+		c.markPartial()
+	}
+}
+
+// worker fetches the pages from the frontier until the frontier is exhausted,
+// the context is cancelled or the page store fails.
+func (c *Crawler) worker(ctx context.Context) {
+	for {
+		current, ok := c.frontier.pop(ctx)
+		if !ok {
+			return
+		}
+
+		if c.pages.err() != nil {
+			c.frontier.done(current.NormalisedURL)
+
+			return
+		}
+
+		c.fetchPage(ctx, current)
+	}
+}
+
+// discovery is a URL found in a link, in a sitemap or given as a seed URL.
+type discovery struct {
+	task       task
+	internal   bool
+	subdomain  bool
+	scopeHost  bool
+	disallowed bool
+	excluded   bool
+	nofollow   bool
+	referrer   Referrer
+}
+
+// discover returns the discovery of rawURL. Internal URLs are checked against
+// the site's robots.txt rules and against the include and exclude patterns
+// so that pages which must not be crawled are never queued. The seed is the seed
+// URL from which rawURL was found.
+func (c *Crawler) discover(ctx context.Context, rawURL, seed string, depth int, fromSitemap bool) (discovery, error) {
+	normalisedURL, err := util.NormaliseURL(rawURL)
+	if err != nil {
+		return discovery{}, fmt.Errorf("error normalising %q: %w", rawURL, err)
+	}
+
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return discovery{}, fmt.Errorf("error parsing the URL %q: %w", rawURL, err)
+	}
+
+	linkType := c.scope.Classify(parsedURL)
+	isInternalLink := linkType != scope.External
+
+	found := discovery{
+		task: task{
+			RawURL:        rawURL,
+			NormalisedURL: normalisedURL,
+			Depth:         depth,
+			FromSitemap:   fromSitemap,
+			Seed:          seed,
+		},
+		internal:   isInternalLink,
+		subdomain:  linkType == scope.Subdomain,
+		scopeHost:  linkType == scope.ScopeHost,
+		disallowed: isInternalLink && !c.robotsRules(ctx, parsedURL).Allowed(parsedURL.RequestURI()),
+		excluded:   isInternalLink && !c.filter.Allowed(parsedURL),
+		nofollow:   false,
+		referrer:   Referrer{Source: "", AnchorText: ""},
+	}
+
+	return found, nil
+}
+
+// fetchPage fetches the internal page of an in-flight task and records every
+// link found on it. The task is marked as done once the links are recorded. If
+// the crawl is cancelled before then the task is left in flight so that the page
+// is fetched again when the crawl is resumed.
+func (c *Crawler) fetchPage(ctx context.Context, current task) {
+	rawCurrentURL := current.RawURL
+	normalisedCurrentURL := current.NormalisedURL
+
+	// The page may have been fetched already as the destination of a redirect.
+	if !c.claimPage(normalisedCurrentURL) {
+		c.completeTask(ctx, current, "", nil)
+
+		return
+	}
+
+	parsedCurrentURL, err := url.Parse(rawCurrentURL)
+	if err != nil {
+		c.pageError("unable to parse the page URL", rawCurrentURL, current.Depth, err)
+		c.completeTask(ctx, current, "", nil)
+
+		return
+	}
+
+	// The robots.txt rules were checked when the page was discovered so
+	// they are only needed here for the crawl delay.
+	rules := c.robotsRules(ctx, parsedCurrentURL)
+
+	c.logger.Debug("fetching page", slog.String("url", rawCurrentURL), slog.Int("depth", current.Depth))
+
+	htmlDoc, info, err := c.getHTMLWithRetries(ctx, parsedCurrentURL.Host, rules.CrawlDelay(), rawCurrentURL)
+
+	// A redirect chain stopped before a URL that the crawler would not
+	// request is recorded with the page rather than as a fetch error.
+	if errors.Is(err, errRedirectStopped) {
+		c.setFetchInfo(normalisedCurrentURL, info, nil)
+
+		c.logger.Info(
+			"redirect not followed",
+			slog.String("url", rawCurrentURL),
+			slog.String("target", info.finalURL),
+			slog.Int("depth", current.Depth),
+			slog.String("reason", info.stopped),
+		)
+		c.completeTask(ctx, current, "", nil)
+
+		return
+	}
+
+	if err != nil {
+		if ctx.Err() != nil {
+			return
+		}
+
+		c.setFetchInfo(normalisedCurrentURL, info, err)
+
+		c.logger.Warn(
+			"unable to fetch page",
+			slog.String("url", rawCurrentURL),
+			slog.Int("depth", current.Depth),
+			slog.Int("status", info.statusCode),
+			slog.Duration("duration", info.latency),
+			slog.Any("error", err),
+		)
+		c.hooks.OnError(ErrorEvent{
+			URL:      rawCurrentURL,
+			Depth:    current.Depth,
+			Status:   info.statusCode,
+			Attempts: info.attempts,
+			Err:      err,
+		})
+		c.completeTask(ctx, current, "", nil)
+
+		return
+	}
+
+	c.setFetchInfo(normalisedCurrentURL, info, nil)
+
+	c.logger.Info(
+		"page fetched",
+		slog.String("url", rawCurrentURL),
+		slog.Int("depth", current.Depth),
+		slog.Int("status", info.statusCode),
+		slog.Duration("duration", info.latency),
+	)
+	c.hooks.OnPageFetched(PageFetchedEvent{
+		URL:         rawCurrentURL,
+		FinalURL:    cmp.Or(info.finalURL, rawCurrentURL),
+		Seed:        current.Seed,
+		Depth:       current.Depth,
+		Status:      info.statusCode,
+		ContentType: info.contentType,
+		Size:        info.size,
+		Duration:    info.latency,
+		Attempts:    info.attempts,
+		Redirects:   info.redirects,
+	})
+
+	// A page that redirects is deduplicated and checked against the
+	// crawl's scope using the final destination of the redirect chain.
+	sourceURL, sourceKey := rawCurrentURL, normalisedCurrentURL
+
+	if len(info.redirects) > 0 {
+		target, err := c.discover(ctx, info.finalURL, current.Seed, current.Depth, false)
+		if err != nil {
+			c.pageError("unable to follow the redirect", rawCurrentURL, current.Depth, err)
+			c.completeTask(ctx, current, "", nil)
+
+			return
+		}
+
+		if target.task.NormalisedURL != normalisedCurrentURL {
+			if !c.claimRedirectTarget(normalisedCurrentURL, target, info) {
+				c.completeTask(ctx, current, "", nil)
+
+				return
+			}
+
+			sourceURL, sourceKey = info.finalURL, target.task.NormalisedURL
+		}
+	}
+
+	// Get all the links from the HTML doc.
+	links, err := util.GetLinksFromHTML(htmlDoc, sourceURL)
+	if err != nil {
+		c.pageError("unable to retrieve the links from the page", sourceURL, current.Depth, err)
+		c.completeTask(ctx, current, "", nil)
+
+		return
+	}
+
+	c.checkCanonical(ctx, sourceURL, sourceKey, current, htmlDoc)
+
+	links = c.applyDirectives(sourceURL, sourceKey, current, info.robotsTags, htmlDoc, links)
+
+	c.completeTask(ctx, current, sourceURL, links)
+}
+
+// completeTask records the links found on the task's page and marks the task as
+// done. Both happen under the same lock so that a saved state never contains a
+// finished page with only some of its links recorded. If the crawl is cancelled
+// while the links are being checked against the robots.txt rules then the task
+// is left in flight. The source URL is the URL of the page that the links were
+// found on which is the final destination if the task's page redirected. The
+// nofollow links are always recorded but they are not followed if the crawler
+// respects the nofollow directives.
+func (c *Crawler) completeTask(ctx context.Context, current task, sourceURL string, links []util.Link) {
+	found := make([]discovery, 0, len(links))
+	discovered := make([]LinkDiscoveredEvent, 0, len(links))
+	skipped := make([]SkippedEvent, 0)
+
+	for ind := range len(links) {
+		link, err := c.discover(ctx, links[ind].URL, current.Seed, current.Depth+1, false)
+		if err != nil {
+			c.pageError("unable to record the link", links[ind].URL, current.Depth+1, err)
+
+			continue
+		}
+
+		link.referrer = Referrer{
+			Source:     sourceURL,
+			AnchorText: links[ind].AnchorText,
+		}
+
+		discovered = append(discovered, LinkDiscoveredEvent{
+			URL:        link.task.RawURL,
+			Source:     sourceURL,
+			AnchorText: links[ind].AnchorText,
+			NoFollow:   links[ind].NoFollow,
+			LinkType:   link.linkType(),
+			Seed:       current.Seed,
+			Depth:      link.task.Depth,
+		})
+
+		if c.respectNofollow && links[ind].NoFollow {
+			link.nofollow = true
+
+			skipped = append(skipped, SkippedEvent{
+				URL:    link.task.RawURL,
+				Source: sourceURL,
+				Seed:   current.Seed,
+				Depth:  link.task.Depth,
+				Reason: SkipReasonNofollow,
+			})
+		}
+
+		found = append(found, link)
+	}
+
+	if ctx.Err() != nil {
+		return
+	}
+
+	c.mu.Lock()
+
+	for ind := range len(found) {
+		// The nofollow links were already reported as skipped.
+		if !c.recordVisit(found[ind]) && !found[ind].nofollow {
+			if reason := c.skipReason(found[ind]); reason != "" {
+				skipped = append(skipped, SkippedEvent{
+					URL:    found[ind].task.RawURL,
+					Source: sourceURL,
+					Seed:   current.Seed,
+					Depth:  found[ind].task.Depth,
+					Reason: reason,
+				})
+			}
+		}
+	}
+
+	c.frontier.done(current.NormalisedURL)
+
+	c.mu.Unlock()
+
+	// The hooks are called without the lock so that they cannot block the other workers.
+	for ind := range len(discovered) {
+		c.hooks.OnLinkDiscovered(discovered[ind])
+	}
+
+	for ind := range len(skipped) {
+		c.hooks.OnSkipped(skipped[ind])
+	}
+}
+
+// addPageVisit adds a record of the visited page's URL to the pages map.
+// If there is already a record of the URL then it's record is updated (incremented)
+// and the method returns true. If the URL is not already recorded then it is created
+// and the method returns false.
+// New internal pages are pushed to the frontier to be fetched.
+func (c *Crawler) addPageVisit(found discovery) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.recordVisit(found)
+}
+
+// recordVisit is the implementation of addPageVisit. The caller must hold the lock.
+// The recorded depth is always the shallowest depth at which the URL was found in a
+// link. URLs taken from the sitemap are marked as being in the sitemap but are not
+// counted as links. Internal pages that are disallowed by robots.txt, that are
+// excluded by the URL filter or that are beyond the maximum depth are recorded but
+// are not pushed to the frontier unless they are later found at a shallower depth.
+// As the query is dropped from the normalised URL, a page that was disallowed or
+// excluded through one URL is pushed to the frontier when it is later found through
+// a URL that is allowed, which is then the URL that is fetched. New URLs are not
+// recorded once the crawl has reached one of its limits. Pages found through the
+// nofollow links that the crawler respects are recorded but are only pushed to the
+// frontier once they are found through a link that is followed. Visits to a variant
+// URL of a page are recorded under the page's canonical URL.
+func (c *Crawler) recordVisit(found discovery) bool {
+	found = c.canonicalDiscovery(found)
+	current := found.task
+
+	stat, exists := c.pages.get(current.NormalisedURL)
+
+	if !exists {
+		stat.seed = current.Seed
+		stat.rawURL = current.RawURL
+		stat.internal = found.internal
+		stat.subdomain = found.subdomain
+		stat.scopeHost = found.scopeHost
+		stat.disallowed = found.disallowed
+		stat.excluded = found.excluded
+		stat.nofollowOnly = found.nofollow
+	}
+
+	if !found.nofollow {
+		stat.nofollowOnly = false
+	}
+
+	if exists && (stat.disallowed || stat.excluded) && !found.disallowed && !found.excluded {
+		stat.rawURL = current.RawURL
+		stat.disallowed = false
+		stat.excluded = false
+	}
+
+	switch {
+	case current.FromSitemap:
+		stat.inSitemap = true
+	case exists && stat.linked:
+		stat.count++
+		stat.depth = min(stat.depth, current.Depth)
+	default:
+		stat.count++
+		stat.depth = current.Depth
+		stat.linked = true
+	}
+
+	if found.referrer.Source != "" {
+		stat.referrers = append(stat.referrers, found.referrer)
+	}
+
+	schedule := c.fetchable(stat) && !stat.scheduled
+
+	if (!exists || schedule) && !c.admit(stat, exists) {
+		if !exists {
+			return false
+		}
+
+		schedule = false
+	}
+
+	if schedule {
+		stat.scheduled = true
+
+		c.frontier.push(current)
+	}
+
+	c.pages.put(current.NormalisedURL, stat)
+
+	return exists
+}
+
+// setFetchInfo records the metadata of the response received when retrieving
+// the page, along with the error if the page could not be retrieved.
+func (c *Crawler) setFetchInfo(normalisedURL string, info fetchInfo, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stat, _ := c.pages.get(normalisedURL)
+	stat.attempts = info.attempts
+	stat.status = info.statusCode
+	stat.contentType = info.contentType
+	stat.size = info.size
+	stat.latency = info.latency
+	stat.redirects = info.redirects
+	stat.redirectLoop = info.redirectLoop
+	stat.redirectStopped = info.stopped
+
+	if info.stopped != "" {
+		stat.redirectsTo = info.finalURL
+
+		if target, err := util.NormaliseURL(info.finalURL); err == nil {
+			stat.redirectsTo = target
+		}
+	}
+
+	// The rest of the response belongs to the final destination of
+	// the redirect chain so the page keeps the status of the redirect.
+	if len(info.redirects) > 0 {
+		stat.status = info.redirects[0].Status
+		stat.contentType = ""
+		stat.size = 0
+	}
+
+	c.fetchedPages++
+	c.fetchedBytes += info.size
+
+	if err != nil {
+		stat.fetchError = err.Error()
+		c.fetchErrors++
+	}
+
+	c.pages.put(normalisedURL, stat)
+
+	c.checkStopConditions()
+}
+
+// markPartial records that the crawl was interrupted before it could finish.
+func (c *Crawler) markPartial() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.partial = true
+}
+
+// result returns the result of the crawl.
+func (c *Crawler) result() Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	summary := c.summary()
+
+	// The records of a crawl kept in a store file are not loaded into memory.
+	if _, inMemory := c.pages.(memoryStore); !inMemory {
+		return Result{
+			Seeds:   c.seedURLs(),
+			Partial: c.partial,
+			Summary: newRecordIndex(c.pages, summary).summary,
+			Records: nil,
+		}
+	}
+
+	return newResult(c.seedURLs(), c.partial, summary, c.pages)
+}
+
+// summary returns the summary of the crawl with its limits. The totals are
+// counted from the pages by the caller. This must be called with the lock held.
+func (c *Crawler) summary() Summary {
+	// The stop reason only records the limits so an interrupted or halted
+	// crawl can still admit new pages when it is resumed. A stop condition
+	// ends the crawl regardless of the limits that were reached before it.
+	stop := c.stopReason
+
+	switch {
+	case c.haltReason != "":
+		stop = c.haltReason
+	case c.partial && stop == StopReasonCompleted:
+		stop = StopReasonInterrupted
+	}
+
+	var duration time.Duration
+
+	switch {
+	case !c.finished.IsZero():
+		duration = c.finished.Sub(c.started)
+	case !c.started.IsZero():
+		duration = time.Since(c.started)
+	}
+
+	summary := Summary{
+		StopReason:       stop,
+		PagesFetched:     0,
+		MaxPages:         max(c.maxPages, 0),
+		ExternalLinks:    0,
+		MaxExternalLinks: max(c.maxExternal, 0),
+		Errors:           0,
+		MaxErrors:        max(c.maxErrors, 0),
+		BytesFetched:     0,
+		MaxBytes:         max(c.maxBytes, 0),
+		DurationMs:       duration.Milliseconds(),
+		MaxDurationMs:    max(c.maxDuration, 0).Milliseconds(),
+	}
+
+	return summary
+}
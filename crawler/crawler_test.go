@@ -0,0 +1,422 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"codeflow.dananglin.me.uk/apollo/web-crawler/crawler/crawlertest"
+	"codeflow.dananglin.me.uk/apollo/web-crawler/internal/util"
+)
+
+func TestCrawler(t *testing.T) {
+	testBaseURL := "https://example.com"
+
+	testCrawler, err := New(
+		[]string{testBaseURL},
+		WithMaxWorkers(1),
+		WithRetries(1, 0, 0),
+	)
+	if err != nil {
+		t.Fatalf("Test 'TestCrawler' FAILED: unexpected error creating the crawler: %v", err)
+	}
+
+	testCasesForPages := []struct {
+		rawURL      string
+		wantVisited bool
+	}{
+		{
+			rawURL:      "https://example.com/tags/linux",
+			wantVisited: false,
+		},
+		{
+			rawURL:      "https://example.com/blog",
+			wantVisited: false,
+		},
+		{
+			rawURL:      "https://example.com/about/contact.html",
+			wantVisited: false,
+		},
+		{
+			rawURL:      "https://example.com/blog",
+			wantVisited: true,
+		},
+	}
+
+	for ind, tc := range slices.All(testCasesForPages) {
+		name := fmt.Sprintf("Adding %s to the pages map", tc.rawURL)
+		t.Run(name, testHasVisited(
+			testCrawler,
+			ind+1,
+			name,
+			tc.rawURL,
+			tc.wantVisited,
+		))
+	}
+}
+
+func TestReachedMaxDepth(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		maxDepth int
+		depth    int
+		want     bool
+	}{
+		{
+			name:     "No depth limit",
+			maxDepth: 0,
+			depth:    100,
+			want:     false,
+		},
+		{
+			name:     "The base URL",
+			maxDepth: 2,
+			depth:    0,
+			want:     false,
+		},
+		{
+			name:     "At the maximum depth",
+			maxDepth: 2,
+			depth:    2,
+			want:     false,
+		},
+		{
+			name:     "Beyond the maximum depth",
+			maxDepth: 2,
+			depth:    3,
+			want:     true,
+		},
+	}
+
+	for ind, tc := range slices.All(cases) {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			testCrawler, err := New(
+				[]string{"https://example.com"},
+				WithMaxWorkers(1),
+				WithMaxDepth(tc.maxDepth),
+				WithRetries(1, 0, 0),
+			)
+			if err != nil {
+				t.Fatalf("Test %d - '%s' FAILED: unexpected error creating the crawler: %v", ind+1, tc.name, err)
+			}
+
+			got := testCrawler.reachedMaxDepth(tc.depth)
+			if got != tc.want {
+				t.Errorf(
+					"Test %d - '%s' FAILED: unexpected result: want %t, got %t",
+					ind+1,
+					tc.name,
+					tc.want,
+					got,
+				)
+			} else {
+				t.Logf(
+					"Test %d - '%s' PASSED: expected result: got %t",
+					ind+1,
+					tc.name,
+					got,
+				)
+			}
+		})
+	}
+}
+
+func testHasVisited(
+	testCrawler *Crawler,
+	testNum int,
+	testName string,
+	rawURL string,
+	wantVisited bool,
+) func(t *testing.T) {
+	return func(t *testing.T) {
+		normalisedURL, err := util.NormaliseURL(rawURL)
+		if err != nil {
+			t.Fatalf(
+				"Test %d - '%s' FAILED: unexpected error: %v",
+				testNum,
+				testName,
+				err,
+			)
+		}
+
+		gotVisited := testCrawler.addPageVisit(discovery{
+			task: task{
+				RawURL:        rawURL,
+				NormalisedURL: normalisedURL,
+				Depth:         1,
+				FromSitemap:   false,
+			},
+			internal:   true,
+			disallowed: false,
+		})
+
+		if gotVisited != wantVisited {
+			t.Errorf(
+				"Test %d - '%s' FAILED: unexpected bool returned after updated pages record: want %t, got %t",
+				testNum,
+				testName,
+				wantVisited,
+				gotVisited,
+			)
+		} else {
+			t.Logf(
+				"Test %d - '%s' PASSED: expected bool returned after updated pages record: got %t",
+				testNum,
+				testName,
+				gotVisited,
+			)
+		}
+	}
+}
+
+func TestVariantLinkedFirst(t *testing.T) {
+	t.Parallel()
+
+	// The variant of the list page with a query is linked before the list page.
+	// Both share the same normalised URL as the query is dropped.
+	cases := []struct {
+		name    string
+		robots  string
+		options []Option
+	}{
+		{
+			name:    "Excluded variant",
+			robots:  "",
+			options: []Option{WithIgnoreRobots(true), WithExclude("re:[?&]sort=")},
+		},
+		{
+			name:    "Variant disallowed by robots.txt",
+			robots:  "User-agent: *\nDisallow: /*?sort=\n",
+			options: nil,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			fetcher := crawlertest.NewFetcher()
+			fetcher.Add("https://example.com/robots.txt", crawlertest.Response{
+				Status: http.StatusOK,
+				Header: http.Header{"Content-Type": []string{"text/plain"}},
+				Body:   tc.robots,
+				Delay:  0,
+				Err:    nil,
+			})
+			fetcher.AddHTML("https://example.com", `<html><body>
+<a href="/list?sort=asc">Sorted</a>
+<a href="/list">List</a>
+</body></html>`)
+			fetcher.AddHTML("https://example.com/list", `<html><body><a href="/list/item">Item</a></body></html>`)
+			fetcher.AddHTML("https://example.com/list/item", `<html><body></body></html>`)
+
+			options := append([]Option{WithMaxWorkers(1), WithRetries(1, 0, 0), WithFetcher(fetcher)}, tc.options...)
+
+			testCrawler, err := New([]string{"https://example.com"}, options...)
+			if err != nil {
+				t.Fatalf("Test %q FAILED: unexpected error creating the crawler: %v", tc.name, err)
+			}
+
+			result, err := testCrawler.Run(context.Background())
+			if err != nil {
+				t.Fatalf("Test %q FAILED: unexpected error running the crawl: %v", tc.name, err)
+			}
+
+			requests := fetcher.Requests()
+
+			if slices.Contains(requests, http.MethodGet+" https://example.com/list?sort=asc") {
+				t.Errorf("Test %q FAILED: the filtered variant was requested: %v", tc.name, requests)
+			}
+
+			for _, record := range slices.All(result.Records) {
+				if record.Link != "example.com/list" && record.Link != "example.com/list/item" {
+					continue
+				}
+
+				if record.Attempts != 1 || record.Excluded || record.Disallowed {
+					t.Errorf("Test %q FAILED: the page %s was not fetched: got %+v", tc.name, record.Link, record)
+				}
+			}
+
+			if len(result.Records) != 3 {
+				t.Errorf("Test %q FAILED: unexpected number of records: want 3, got %d", tc.name, len(result.Records))
+			}
+		})
+	}
+}
+
+func TestCancelledCrawl(t *testing.T) {
+	t.Parallel()
+
+	slowResponse := crawlertest.Response{
+		Status: http.StatusOK,
+		Header: http.Header{"Content-Type": []string{"text/plain"}},
+		Body:   "",
+		Delay:  10 * time.Second,
+		Err:    nil,
+	}
+
+	cases := []struct {
+		name        string
+		slowURL     string
+		wantRecords int
+	}{
+		{
+			name:        "Cancelled while fetching a page",
+			slowURL:     "https://example.com/slow",
+			wantRecords: 2,
+		},
+		{
+			name:        "Cancelled while fetching the robots.txt file",
+			slowURL:     "https://example.com/robots.txt",
+			wantRecords: 0,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			fetcher := crawlertest.NewFetcher()
+			fetcher.AddHTML("https://example.com", `<html><body><a href="/slow">Slow</a></body></html>`)
+			fetcher.AddHTML("https://example.com/slow", `<html><body></body></html>`)
+			fetcher.Add(tc.slowURL, slowResponse)
+
+			stateFile := filepath.Join(t.TempDir(), "state.json")
+
+			testCrawler, err := New(
+				[]string{"https://example.com"},
+				WithMaxWorkers(1),
+				WithRetries(1, 0, 0),
+				WithFetcher(fetcher),
+				WithStateFile(stateFile, 0),
+			)
+			if err != nil {
+				t.Fatalf("Test %q FAILED: unexpected error creating the crawler: %v", tc.name, err)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+
+			result, err := testCrawler.Run(ctx)
+			if err != nil {
+				t.Fatalf("Test %q FAILED: unexpected error running the crawl: %v", tc.name, err)
+			}
+
+			if !result.Partial || len(result.Records) != tc.wantRecords {
+				t.Errorf(
+					"Test %q FAILED: unexpected result of the cancelled crawl: want a partial crawl with %d records, got %+v",
+					tc.name,
+					tc.wantRecords,
+					result,
+				)
+			}
+
+			for _, record := range slices.All(result.Records) {
+				if record.Disallowed {
+					t.Errorf("Test %q FAILED: the page %s was reported as disallowed", tc.name, record.Link)
+				}
+			}
+
+			var report strings.Builder
+
+			if err := testCrawler.WriteReport(&report, "", "text", false); err != nil {
+				t.Fatalf("Test %q FAILED: unexpected error writing the report: %v", tc.name, err)
+			}
+
+			if !strings.Contains(report.String(), "PARTIAL REPORT") {
+				t.Errorf("Test %q FAILED: the report does not show that the crawl is partial:\n%s", tc.name, report.String())
+			}
+
+			// The resumed crawl fetches the pages that were not fetched before the cancellation.
+			fetcher.Add(tc.slowURL, crawlertest.Response{
+				Status: http.StatusOK,
+				Header: slowResponse.Header,
+				Body:   "",
+				Delay:  0,
+				Err:    nil,
+			})
+
+			resumedCrawler, err := New(
+				[]string{"https://example.com"},
+				WithMaxWorkers(1),
+				WithRetries(1, 0, 0),
+				WithFetcher(fetcher),
+				WithStateFile(stateFile, 0),
+				WithResume(true),
+			)
+			if err != nil {
+				t.Fatalf("Test %q FAILED: unexpected error creating the resumed crawler: %v", tc.name, err)
+			}
+
+			result, err = resumedCrawler.Run(context.Background())
+			if err != nil {
+				t.Fatalf("Test %q FAILED: unexpected error resuming the crawl: %v", tc.name, err)
+			}
+
+			if result.Partial || len(result.Records) != 2 {
+				t.Errorf("Test %q FAILED: unexpected result of the resumed crawl: got %+v", tc.name, result)
+			}
+
+			for _, record := range slices.All(result.Records) {
+				if record.Disallowed || record.Status != http.StatusOK {
+					t.Errorf("Test %q FAILED: the page %s was not fetched when the crawl was resumed: got %+v", tc.name, record.Link, record)
+				}
+			}
+		})
+	}
+}
+
+func TestScopeHostLinkType(t *testing.T) {
+	t.Parallel()
+
+	fetcher := crawlertest.NewFetcher()
+	fetcher.AddHTML("https://example.com", `<html><body>
+<a href="https://shop.example.net">Shop</a>
+<a href="https://docs.example.com">Docs</a>
+</body></html>`)
+	fetcher.AddHTML("https://shop.example.net", `<html><body></body></html>`)
+
+	testCrawler, err := New(
+		[]string{"https://example.com"},
+		WithMaxWorkers(1),
+		WithIgnoreRobots(true),
+		WithRetries(1, 0, 0),
+		WithScope("hosts", "shop.example.net"),
+		WithFetcher(fetcher),
+	)
+	if err != nil {
+		t.Fatalf("Test 'TestScopeHostLinkType' FAILED: unexpected error creating the crawler: %v", err)
+	}
+
+	result, err := testCrawler.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Test 'TestScopeHostLinkType' FAILED: unexpected error running the crawl: %v", err)
+	}
+
+	want := map[string]string{
+		"example.com":      "internal",
+		"shop.example.net": "scope-host",
+		"docs.example.com": "external",
+	}
+
+	got := make(map[string]string)
+
+	for _, record := range slices.All(result.Records) {
+		got[record.Link] = record.LinkType
+	}
+
+	if !maps.Equal(got, want) {
+		t.Errorf("Test 'TestScopeHostLinkType' FAILED: unexpected link types: want %v, got %v", want, got)
+	}
+}
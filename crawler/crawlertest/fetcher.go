@@ -0,0 +1,167 @@
+// Package crawlertest provides an in-memory Fetcher for testing crawls
+// without a network.
+package crawlertest
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Response is the response that the fetcher returns for a URL.
+type Response struct {
+	Status int
+	Header http.Header
+	Body   string
+
+	// Delay is the time taken to respond. The request fails if its
+	// context is cancelled before then.
+	Delay time.Duration
+
+	// Err is returned instead of the response if it is set.
+	Err error
+}
+
+// Fetcher is an in-memory implementation of the crawler's Fetcher. It returns
+// the responses that were added for the requested URLs and a 404 Not Found
+// response for every other URL. It records the requests that it receives.
+// A Fetcher is safe for concurrent use.
+type Fetcher struct {
+	mu        sync.Mutex
+	responses map[string]Response
+	requests  []string
+}
+
+// NewFetcher returns a fetcher without any responses.
+func NewFetcher() *Fetcher {
+	return &Fetcher{
+		mu:        sync.Mutex{},
+		responses: make(map[string]Response),
+		requests:  nil,
+	}
+}
+
+// Add sets the response for the URL.
+func (f *Fetcher) Add(rawURL string, response Response) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.responses[rawURL] = response
+}
+
+// AddHTML sets a 200 OK response with the HTML document for the URL.
+func (f *Fetcher) AddHTML(rawURL, body string) {
+	f.Add(rawURL, Response{
+		Status: http.StatusOK,
+		Header: http.Header{"Content-Type": []string{"text/html; charset=utf-8"}},
+		Body:   body,
+		Delay:  0,
+		Err:    nil,
+	})
+}
+
+// AddRedirect sets a redirect response with the status to the location for the URL.
+func (f *Fetcher) AddRedirect(rawURL string, status int, location string) {
+	f.Add(rawURL, Response{
+		Status: status,
+		Header: http.Header{"Location": []string{location}},
+		Body:   "",
+		Delay:  0,
+		Err:    nil,
+	})
+}
+
+// AddError sets the error returned for the URL.
+func (f *Fetcher) AddError(rawURL string, err error) {
+	f.Add(rawURL, Response{
+		Status: 0,
+		Header: nil,
+		Body:   "",
+		Delay:  0,
+		Err:    err,
+	})
+}
+
+// Fetch returns the response for the request's URL.
+func (f *Fetcher) Fetch(request *http.Request) (*http.Response, error) {
+	if err := request.Context().Err(); err != nil {
+		return nil, fmt.Errorf("%s %q: %w", request.Method, request.URL, err)
+	}
+
+	rawURL := request.URL.String()
+
+	f.mu.Lock()
+
+	f.requests = append(f.requests, request.Method+" "+rawURL)
+
+	response, exists := f.responses[rawURL]
+
+	f.mu.Unlock()
+
+	if !exists {
+		response = Response{
+			Status: http.StatusNotFound,
+			Header: http.Header{"Content-Type": []string{"text/plain; charset=utf-8"}},
+			Body:   "404 page not found",
+			Delay:  0,
+			Err:    nil,
+		}
+	}
+
+	if response.Delay > 0 {
+		timer := time.NewTimer(response.Delay)
+		defer timer.Stop()
+
+		select {
+		case <-request.Context().Done():
+			return nil, fmt.Errorf("%s %q: %w", request.Method, request.URL, request.Context().Err())
+		case <-timer.C:
+		}
+	}
+
+	if response.Err != nil {
+		return nil, fmt.Errorf("%s %q: %w", request.Method, request.URL, response.Err)
+	}
+
+	header := response.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+
+	header.Set("Content-Length", strconv.Itoa(len(response.Body)))
+
+	body := response.Body
+	if request.Method == http.MethodHead {
+		body = ""
+	}
+
+	return &http.Response{
+		Status:           strconv.Itoa(response.Status) + " " + http.StatusText(response.Status),
+		StatusCode:       response.Status,
+		Proto:            "HTTP/1.1",
+		ProtoMajor:       1,
+		ProtoMinor:       1,
+		Header:           header,
+		Body:             io.NopCloser(strings.NewReader(body)),
+		ContentLength:    int64(len(response.Body)),
+		TransferEncoding: nil,
+		Close:            false,
+		Uncompressed:     false,
+		Trailer:          nil,
+		Request:          request,
+		TLS:              nil,
+	}, nil
+}
+
+// Requests returns the requests received by the fetcher in the order in which
+// they were received. Each request is formatted as the method followed by the URL.
+func (f *Fetcher) Requests() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]string(nil), f.requests...)
+}
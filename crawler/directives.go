@@ -0,0 +1,62 @@
+package crawler
+
+import (
+	"slices"
+
+	"codeflow.dananglin.me.uk/apollo/web-crawler/internal/robots"
+	"codeflow.dananglin.me.uk/apollo/web-crawler/internal/util"
+)
+
+// applyDirectives records the robots directives of the fetched page along with the
+// number of links on the page marked with rel="nofollow". The directives are taken
+// from the robots meta tags and from the X-Robots-Tag headers of the response. Every
+// link on a page with the nofollow directive is marked as a nofollow link in the
+// returned links; the links are only removed when they are recorded so that they
+// are still passed to the hooks as discovered links. Each X-Robots-Tag header is
+// parsed on its own so that a user agent prefix only applies within its header.
+func (c *Crawler) applyDirectives(
+	pageURL, pageKey string,
+	current task,
+	robotsTags []string,
+	htmlDoc string,
+	links []util.Link,
+) []util.Link {
+	var directives robots.Directives
+
+	for _, robotsTag := range slices.All(robotsTags) {
+		directives = directives.Merge(robots.ParseDirectives(robotsTag, c.userAgent))
+	}
+
+	metaContent, err := util.GetMetaRobotsFromHTML(htmlDoc)
+	if err != nil {
+		c.pageError("unable to retrieve the robots meta tags", pageURL, current.Depth, err)
+	} else {
+		directives = directives.Merge(robots.ParseDirectives(metaContent, c.userAgent))
+	}
+
+	nofollowLinks := 0
+
+	for ind := range slices.All(links) {
+		if links[ind].NoFollow {
+			nofollowLinks++
+		}
+
+		links[ind].NoFollow = links[ind].NoFollow || directives.NoFollow
+	}
+
+	c.setDirectives(pageKey, directives, nofollowLinks)
+
+	return links
+}
+
+// setDirectives records the robots directives of the page.
+func (c *Crawler) setDirectives(normalisedURL string, directives robots.Directives, nofollowLinks int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stat, _ := c.pages.get(normalisedURL)
+	stat.noIndex = directives.NoIndex
+	stat.noFollow = directives.NoFollow
+	stat.nofollowLinks = nofollowLinks
+	c.pages.put(normalisedURL, stat)
+}
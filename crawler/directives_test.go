@@ -0,0 +1,120 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestRobotsDirectives(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(
+			w,
+			`<html><body><a href="/sponsored" rel="nofollow">Ad</a><a href="https://example.org/ad" rel="nofollow">External ad</a>`+
+				`<a href="/tagged">Tagged</a><a href="/meta">Meta</a></body></html>`,
+		)
+	})
+	mux.HandleFunc("/tagged", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		// The user agent prefix of the first header does not apply to the second one.
+		w.Header().Add("X-Robots-Tag", "otherbot: noindex")
+		w.Header().Add("X-Robots-Tag", "nofollow")
+		fmt.Fprint(w, `<html><body><a href="/from-tagged">Next</a></body></html>`)
+	})
+	mux.HandleFunc("/meta", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><head><meta name="robots" content="noindex"></head><body></body></html>`)
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><body></body></html>`)
+	})
+
+	// The server must outlive the parallel subtests.
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	cases := []struct {
+		name            string
+		respectNofollow bool
+		wantFetched     []string
+	}{
+		{
+			name:            "Directives are only reported",
+			respectNofollow: false,
+			wantFetched:     []string{"", "/from-tagged", "/meta", "/sponsored", "/tagged"},
+		},
+		{
+			name:            "Directives are respected",
+			respectNofollow: true,
+			wantFetched:     []string{"", "/meta", "/tagged"},
+		},
+	}
+
+	for ind, tc := range slices.All(cases) {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			testCrawler, err := New(
+				[]string{server.URL},
+				WithMaxWorkers(1),
+				WithMaxPages(20),
+				WithIgnoreRobots(true),
+				WithRespectNofollow(tc.respectNofollow),
+				WithRetries(1, 0, 0),
+			)
+			if err != nil {
+				t.Fatalf("Test %d - '%s' FAILED: unexpected error creating the crawler: %v", ind+1, tc.name, err)
+			}
+
+			testCrawler.crawl(context.Background())
+
+			host := testCrawler.seeds[0].Hostname()
+			fetched := make([]string, 0)
+
+			for link, stat := range testCrawler.pages.all() {
+				if stat.attempts > 0 {
+					fetched = append(fetched, link[len(host):])
+				}
+			}
+
+			slices.Sort(fetched)
+
+			if !slices.Equal(fetched, tc.wantFetched) {
+				t.Errorf("Test %d - '%s' FAILED: unexpected pages fetched: want %v, got %v", ind+1, tc.name, tc.wantFetched, fetched)
+			}
+
+			root, tagged, meta := testCrawler.page(host), testCrawler.page(host+"/tagged"), testCrawler.page(host+"/meta")
+
+			if root.nofollowLinks != 2 || !tagged.noFollow || tagged.noIndex || !meta.noIndex || meta.noFollow {
+				t.Errorf(
+					"Test %d - '%s' FAILED: unexpected directives: got %d nofollow links on the base URL, %+v and %+v",
+					ind+1,
+					tc.name,
+					root.nofollowLinks,
+					tagged,
+					meta,
+				)
+			} else {
+				t.Logf("Test %d - '%s' PASSED: expected pages fetched and directives: got %v", ind+1, tc.name, fetched)
+			}
+
+			// The nofollow links are recorded even when they are not followed, and
+			// the external link is only checked if it is followed.
+			for _, link := range []string{host + "/sponsored", host + "/from-tagged", "example.org/ad"} {
+				got := testCrawler.page(link)
+
+				if got.count != 1 || len(got.referrers) != 1 || got.nofollowOnly != tc.respectNofollow {
+					t.Errorf("Test %d - '%s' FAILED: unexpected record of the nofollow link to %s: got %+v", ind+1, tc.name, link, got)
+				}
+			}
+		})
+	}
+}
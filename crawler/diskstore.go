@@ -0,0 +1,301 @@
+package crawler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"maps"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// diskStoreBatchSize is the number of pages read from the disk store in a
+// single transaction when iterating over the pages, and the number of pages
+// that are buffered before they are written to the disk store together.
+const diskStoreBatchSize = 1000
+
+var pagesBucket = []byte("pages")
+
+// diskStore keeps the pages in an embedded key-value database on disk so that
+// the size of a crawl is not limited by the available memory. The pages are
+// stored in their saved form as JSON. The database is only a working store for
+// a single crawl so its writes are not synced to disk; use the state file to
+// resume a crawl.
+//
+// Writing every page in its own transaction would commit the database once per
+// put, so the pages are buffered in memory and written in a single transaction
+// once the buffer is full, or before the pages are iterated over and when the
+// store is closed. The buffered pages are read from the buffer.
+type diskStore struct {
+	db      *bolt.DB
+	count   int
+	pending map[string]pageStat
+
+	mu       sync.Mutex
+	firstErr error
+}
+
+// openDiskStore opens the database at the path, creating it if needed.
+// Any pages stored in the database by a previous crawl are removed.
+func openDiskStore(path string) (*diskStore, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second, NoSync: true, NoFreelistSync: true})
+	if err != nil {
+		return nil, fmt.Errorf("error opening %s: %w", path, err)
+	}
+
+	store := diskStore{
+		db:       db,
+		count:    0,
+		pending:  make(map[string]pageStat),
+		mu:       sync.Mutex{},
+		firstErr: nil,
+	}
+
+	if err := store.reset(); err != nil {
+		db.Close()
+
+		return nil, err
+	}
+
+	return &store, nil
+}
+
+func (s *diskStore) get(normalisedURL string) (pageStat, bool) {
+	if stat, ok := s.pending[normalisedURL]; ok {
+		return stat, true
+	}
+
+	var (
+		saved  savedPage
+		exists bool
+	)
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(pagesBucket).Get([]byte(normalisedURL))
+		if data == nil {
+			return nil
+		}
+
+		exists = true
+
+		return json.Unmarshal(data, &saved)
+	})
+	if err != nil {
+		s.setErr(fmt.Errorf("error reading %s from the page store: %w", normalisedURL, err))
+
+		return pageStat{}, false
+	}
+
+	return saved.pageStat(), exists
+}
+
+func (s *diskStore) put(normalisedURL string, stat pageStat) {
+	if _, ok := s.pending[normalisedURL]; !ok {
+		if _, exists := s.get(normalisedURL); !exists {
+			s.count++
+		}
+	}
+
+	s.pending[normalisedURL] = stat
+
+	if len(s.pending) >= diskStoreBatchSize {
+		s.flush()
+	}
+}
+
+// flush writes the buffered pages to the database in a single transaction.
+func (s *diskStore) flush() {
+	if len(s.pending) == 0 {
+		return
+	}
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(pagesBucket)
+
+		for normalisedURL, stat := range maps.All(s.pending) {
+			data, err := json.Marshal(newSavedPage(stat))
+			if err != nil {
+				return fmt.Errorf("error marshalling %s: %w", normalisedURL, err)
+			}
+
+			if err := bucket.Put([]byte(normalisedURL), data); err != nil {
+				return fmt.Errorf("error writing %s: %w", normalisedURL, err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		s.setErr(fmt.Errorf("error writing to the page store: %w", err))
+	}
+
+	clear(s.pending)
+}
+
+func (s *diskStore) len() int {
+	return s.count
+}
+
+// all iterates over the pages in batches so that no transaction is held open
+// while the caller handles the pages. The buffered pages are written first.
+func (s *diskStore) all() iter.Seq2[string, pageStat] {
+	return func(yield func(string, pageStat) bool) {
+		s.flush()
+
+		var after []byte
+
+		for {
+			keys, pages, err := s.batch(after)
+			if err != nil {
+				s.setErr(err)
+
+				return
+			}
+
+			for ind := range keys {
+				if !yield(keys[ind], pages[ind]) {
+					return
+				}
+			}
+
+			if len(keys) < diskStoreBatchSize {
+				return
+			}
+
+			after = []byte(keys[len(keys)-1])
+		}
+	}
+}
+
+// batch reads the next batch of pages stored after the given key.
+func (s *diskStore) batch(after []byte) ([]string, []pageStat, error) {
+	keys := make([]string, 0, diskStoreBatchSize)
+	pages := make([]pageStat, 0, diskStoreBatchSize)
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(pagesBucket).Cursor()
+
+		key, data := cursor.First()
+		if after != nil {
+			key, data = cursor.Seek(after)
+			if key != nil && bytes.Equal(key, after) {
+				key, data = cursor.Next()
+			}
+		}
+
+		for ; key != nil && len(keys) < diskStoreBatchSize; key, data = cursor.Next() {
+			var saved savedPage
+
+			if err := json.Unmarshal(data, &saved); err != nil {
+				return fmt.Errorf("error reading %s from the page store: %w", key, err)
+			}
+
+			keys = append(keys, string(key))
+			pages = append(pages, saved.pageStat())
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("error iterating over the page store: %w", err)
+	}
+
+	return keys, pages, nil
+}
+
+// snapshot reads the pages in a read-only transaction which sees the pages as
+// they were when it began. Writes are not blocked by the transaction, except for
+// a write that needs to grow the database which waits until it is released.
+// The buffered pages are written before the transaction begins.
+func (s *diskStore) snapshot() (iter.Seq2[string, pageStat], func()) {
+	s.flush()
+
+	tx, err := s.db.Begin(false)
+	if err != nil {
+		s.setErr(fmt.Errorf("error reading the page store: %w", err))
+
+		return func(func(string, pageStat) bool) {}, func() {}
+	}
+
+	pages := func(yield func(string, pageStat) bool) {
+		cursor := tx.Bucket(pagesBucket).Cursor()
+
+		for key, data := cursor.First(); key != nil; key, data = cursor.Next() {
+			var saved savedPage
+
+			if err := json.Unmarshal(data, &saved); err != nil {
+				s.setErr(fmt.Errorf("error reading %s from the page store: %w", key, err))
+
+				return
+			}
+
+			if !yield(string(key), saved.pageStat()) {
+				return
+			}
+		}
+	}
+
+	release := func() {
+		_ = tx.Rollback()
+	}
+
+	return pages, release
+}
+
+func (s *diskStore) clear() {
+	clear(s.pending)
+
+	if err := s.reset(); err != nil {
+		s.setErr(err)
+	}
+}
+
+// reset replaces the bucket of pages with an empty one.
+func (s *diskStore) reset() error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.DeleteBucket(pagesBucket); err != nil && !errors.Is(err, bolt.ErrBucketNotFound) {
+			return err
+		}
+
+		_, err := tx.CreateBucket(pagesBucket)
+
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("error clearing the page store: %w", err)
+	}
+
+	s.count = 0
+
+	return nil
+}
+
+func (s *diskStore) err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.firstErr
+}
+
+func (s *diskStore) setErr(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.firstErr == nil {
+		s.firstErr = err
+	}
+}
+
+func (s *diskStore) close() error {
+	s.flush()
+
+	if err := s.db.Close(); err != nil {
+		return fmt.Errorf("error closing the page store: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,207 @@
+package crawler
+
+import (
+	"cmp"
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"syscall"
+)
+
+// Error classes of the external links that could not be checked.
+const (
+	errorClassTimeout           = "timeout"
+	errorClassDNS               = "dns"
+	errorClassConnectionRefused = "connection-refused"
+	errorClassConnectionReset   = "connection-reset"
+	errorClassTLS               = "tls"
+	errorClassNetwork           = "network"
+)
+
+// externalLink is an external link that is waiting to be checked.
+type externalLink struct {
+	rawURL        string
+	normalisedURL string
+}
+
+// checkExternalLinks checks every unique HTTP(S) external link recorded during the
+// crawl with a HEAD request, falling back to a GET request if the HEAD request fails.
+// The links are checked by a separate pool of workers after the crawl has finished
+// and the status code or the class of the error is recorded for each link. Links
+// that were already checked before the crawl was resumed are not checked again.
+// checkExternalLinks blocks until all the links are checked, the context is cancelled
+// or the crawl is halted by one of its stop conditions. The crawl is marked as partial
+// if any of the links were not checked.
+func (c *Crawler) checkExternalLinks(ctx context.Context) {
+	c.mu.Lock()
+
+	links := make([]externalLink, 0)
+
+	for normalisedURL, stat := range c.pages.all() {
+		if stat.internal || stat.checked || stat.nofollowOnly || !isHTTPURL(stat.rawURL) {
+			continue
+		}
+
+		links = append(links, externalLink{rawURL: stat.rawURL, normalisedURL: normalisedURL})
+	}
+
+	c.mu.Unlock()
+
+	slices.SortFunc(links, func(a, b externalLink) int {
+		return cmp.Compare(a.normalisedURL, b.normalisedURL)
+	})
+
+	jobs := make(chan externalLink)
+
+	var (
+		waitGroup sync.WaitGroup
+		checked   atomic.Int64
+	)
+
+	for range c.externalWorkers {
+		waitGroup.Add(1)
+
+		go func() {
+			defer waitGroup.Done()
+
+			for link := range jobs {
+				status, errorClass, ok := c.checkLink(ctx, link.rawURL)
+				if !ok || ctx.Err() != nil {
+					continue
+				}
+
+				c.setCheckResult(link.normalisedURL, status, errorClass)
+				checked.Add(1)
+			}
+		}()
+	}
+
+	for _, link := range slices.All(links) {
+		if ctx.Err() != nil || c.halted() {
+			break
+		}
+
+		select {
+		case jobs <- link:
+		case <-ctx.Done():
+		}
+	}
+
+	close(jobs)
+
+	waitGroup.Wait()
+
+	if checked.Load() < int64(len(links)) {
+		c.markPartial()
+	}
+}
+
+// checkLink requests the external link and returns the status code of the response
+// or the class of the error if no response was received. It returns false if the
+// link was not checked because the crawl was halted before a request was sent.
+func (c *Crawler) checkLink(ctx context.Context, rawURL string) (int, string, bool) {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return 0, errorClassNetwork, true
+	}
+
+	if err := c.limiter.wait(ctx, parsedURL.Host, 0); err != nil {
+		return 0, errorClassFor(err), true
+	}
+
+	if c.halted() {
+		return 0, "", false
+	}
+
+	status, err := c.checkURL(ctx, http.MethodHead, rawURL)
+	if err == nil && status < 400 {
+		return status, "", true
+	}
+
+	if ctx.Err() != nil {
+		return 0, errorClassFor(ctx.Err()), true
+	}
+
+	// Some servers don't support HEAD requests or respond to them
+	// differently so the link is requested again with GET.
+	if err := c.limiter.wait(ctx, parsedURL.Host, 0); err != nil {
+		return 0, errorClassFor(err), true
+	}
+
+	if c.halted() {
+		return 0, "", false
+	}
+
+	status, err = c.checkURL(ctx, http.MethodGet, rawURL)
+	if err != nil {
+		return 0, errorClassFor(err), true
+	}
+
+	return status, "", true
+}
+
+// checkURL sends a request with the given method to the URL and returns the
+// status code of the response. The body of the response is not read.
+func (c *Crawler) checkURL(ctx context.Context, method, rawURL string) (int, error) {
+	resp, _, err := c.do(ctx, method, rawURL, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	resp.Body.Close()
+
+	return resp.StatusCode, nil
+}
+
+// errorClassFor returns the class of the error from a request.
+func errorClassFor(err error) string {
+	var (
+		netErr    net.Error
+		dnsErr    *net.DNSError
+		certErr   *tls.CertificateVerificationError
+		recordErr tls.RecordHeaderError
+	)
+
+	switch {
+	case errors.As(err, &dnsErr):
+		return errorClassDNS
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return errorClassTimeout
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return errorClassConnectionRefused
+	case errors.Is(err, syscall.ECONNRESET):
+		return errorClassConnectionReset
+	case errors.As(err, &certErr), errors.As(err, &recordErr):
+		return errorClassTLS
+	default:
+		return errorClassNetwork
+	}
+}
+
+// setCheckResult records the result of the check of an external link.
+func (c *Crawler) setCheckResult(normalisedURL string, status int, errorClass string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stat, _ := c.pages.get(normalisedURL)
+	stat.checked = true
+	stat.status = status
+	stat.errorClass = errorClass
+	c.pages.put(normalisedURL, stat)
+}
+
+// isHTTPURL evaluates to true if the URL uses the HTTP or HTTPS scheme.
+func isHTTPURL(rawURL string) bool {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+
+	return parsedURL.Scheme == "http" || parsedURL.Scheme == "https"
+}
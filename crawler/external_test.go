@@ -0,0 +1,89 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCheckExternalLinks(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/no-head", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/gone", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusGone)
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	// A closed server refuses every connection.
+	closedServer := httptest.NewServer(http.NotFoundHandler())
+	closedServer.Close()
+
+	testCrawler, err := New(
+		[]string{"https://example.com"},
+		WithMaxWorkers(1),
+		WithExternalWorkers(2),
+		WithIgnoreRobots(true),
+		WithRetries(1, 0, 0),
+	)
+	if err != nil {
+		t.Fatalf("Test 'TestCheckExternalLinks' FAILED: unexpected error creating the crawler: %v", err)
+	}
+
+	testCrawler.pages = memoryStore{
+		"example.com":    {count: 1, internal: true, rawURL: "https://example.com", attempts: 1},
+		"server/ok":      {count: 1, rawURL: server.URL + "/ok"},
+		"server/no-head": {count: 1, rawURL: server.URL + "/no-head"},
+		"server/gone":    {count: 1, rawURL: server.URL + "/gone"},
+		"closed/page":    {count: 1, rawURL: closedServer.URL + "/page"},
+		"mail":           {count: 1, rawURL: "mailto:someone@example.com"},
+	}
+
+	testCrawler.checkExternalLinks(context.Background())
+
+	cases := []struct {
+		link           string
+		wantChecked    bool
+		wantStatus     int
+		wantErrorClass string
+	}{
+		{link: "example.com", wantChecked: false, wantStatus: 0, wantErrorClass: ""},
+		{link: "server/ok", wantChecked: true, wantStatus: http.StatusOK, wantErrorClass: ""},
+		{link: "server/no-head", wantChecked: true, wantStatus: http.StatusOK, wantErrorClass: ""},
+		{link: "server/gone", wantChecked: true, wantStatus: http.StatusGone, wantErrorClass: ""},
+		{link: "closed/page", wantChecked: true, wantStatus: 0, wantErrorClass: errorClassConnectionRefused},
+		{link: "mail", wantChecked: false, wantStatus: 0, wantErrorClass: ""},
+	}
+
+	for _, tc := range cases {
+		got := testCrawler.page(tc.link)
+
+		if got.checked != tc.wantChecked || got.status != tc.wantStatus || got.errorClass != tc.wantErrorClass {
+			t.Errorf(
+				"Test 'TestCheckExternalLinks' FAILED: unexpected result for %s: want checked=%t status=%d error=%q, got checked=%t status=%d error=%q",
+				tc.link,
+				tc.wantChecked,
+				tc.wantStatus,
+				tc.wantErrorClass,
+				got.checked,
+				got.status,
+				got.errorClass,
+			)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package crawler
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+// DefaultTimeout is the time limit of every request sent by the default fetcher.
+const DefaultTimeout = 10 * time.Second
+
+// Fetcher sends the crawler's HTTP requests. Redirects must not be followed;
+// the response to a redirect is returned as it is and the crawler follows the
+// redirect itself so that every hop of the chain is recorded. A fetcher is used
+// by all the crawler's workers so it must be safe for concurrent use.
+type Fetcher interface {
+	Fetch(request *http.Request) (*http.Response, error)
+}
+
+// HTTPFetcher is the default Fetcher. It sends every request with the same HTTP
+// client so that connections are reused across requests and workers.
+type HTTPFetcher struct {
+	client *http.Client
+}
+
+// NewHTTPFetcher returns a fetcher that sends the requests with a copy of the client.
+// The client's timeout and transport are kept but its redirect policy is replaced
+// so that redirects are not followed. A client created with NewHTTPClient and the
+// default timeout is used if the client is nil.
+func NewHTTPFetcher(client *http.Client) *HTTPFetcher {
+	if client == nil {
+		client = NewHTTPClient(DefaultTimeout)
+	}
+
+	shared := *client
+	shared.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	return &HTTPFetcher{client: &shared}
+}
+
+// Fetch sends the request and returns the response.
+func (f *HTTPFetcher) Fetch(request *http.Request) (*http.Response, error) {
+	return f.client.Do(request) //nolint:wrapcheck // The caller wraps the error with the request's context.
+}
+
+// NewHTTPClient returns an HTTP client with the given time limit for each request
+// and a transport that keeps a pool of idle connections to every host so that
+// connections are reused between the requests to the same site.
+func NewHTTPClient(timeout time.Duration) *http.Client {
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   timeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   10,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   timeout,
+		ExpectContinueTimeout: time.Second,
+	}
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   timeout,
+	}
+}
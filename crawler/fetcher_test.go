@@ -0,0 +1,159 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"testing"
+
+	"codeflow.dananglin.me.uk/apollo/web-crawler/crawler/crawlertest"
+)
+
+func TestCrawlWithFakeFetcher(t *testing.T) {
+	t.Parallel()
+
+	fetcher := crawlertest.NewFetcher()
+	fetcher.Add("https://example.com/robots.txt", crawlertest.Response{
+		Status: http.StatusOK,
+		Header: http.Header{"Content-Type": []string{"text/plain"}},
+		Body:   "User-agent: *\nDisallow: /private\n",
+		Delay:  0,
+		Err:    nil,
+	})
+	fetcher.AddHTML("https://example.com", `<html><body>
+<a href="/about">About</a>
+<a href="/old">Old</a>
+<a href="/private">Private</a>
+<a href="/missing">Missing</a>
+<a href="/unreachable">Unreachable</a>
+<a href="https://example.org/">Example</a>
+</body></html>`)
+	fetcher.AddHTML("https://example.com/about", `<html><body><a href="/">Home</a></body></html>`)
+	fetcher.AddRedirect("https://example.com/old", http.StatusMovedPermanently, "/new")
+	fetcher.AddHTML("https://example.com/new", `<html><body></body></html>`)
+	fetcher.AddError("https://example.com/unreachable", errors.New("connection refused"))
+	fetcher.AddHTML("https://example.org/", `<html><body></body></html>`)
+
+	testCrawler, err := New(
+		[]string{"https://example.com"},
+		WithMaxWorkers(1),
+		WithRetries(1, 0, 0),
+		WithExternalLinkCheck(true),
+		WithFetcher(fetcher),
+	)
+	if err != nil {
+		t.Fatalf("Test 'TestCrawlWithFakeFetcher' FAILED: unexpected error creating the crawler: %v", err)
+	}
+
+	result, err := testCrawler.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Test 'TestCrawlWithFakeFetcher' FAILED: unexpected error running the crawl: %v", err)
+	}
+
+	records := make(map[string]Record)
+
+	for _, record := range slices.All(result.Records) {
+		records[record.Link] = record
+	}
+
+	cases := []struct {
+		link       string
+		wantStatus int
+		wantError  bool
+	}{
+		{link: "example.com", wantStatus: http.StatusOK, wantError: false},
+		{link: "example.com/about", wantStatus: http.StatusOK, wantError: false},
+		{link: "example.com/old", wantStatus: http.StatusMovedPermanently, wantError: false},
+		{link: "example.com/new", wantStatus: http.StatusOK, wantError: false},
+		{link: "example.com/missing", wantStatus: http.StatusNotFound, wantError: true},
+		{link: "example.com/unreachable", wantStatus: 0, wantError: true},
+		{link: "example.org", wantStatus: http.StatusOK, wantError: false},
+	}
+
+	for _, tc := range slices.All(cases) {
+		got, exists := records[tc.link]
+		if !exists {
+			t.Errorf("Test 'TestCrawlWithFakeFetcher' FAILED: the link %s was not recorded", tc.link)
+
+			continue
+		}
+
+		if got.Status != tc.wantStatus || (got.Error != "") != tc.wantError {
+			t.Errorf(
+				"Test 'TestCrawlWithFakeFetcher' FAILED: unexpected record for %s: want status %d and error %t, got %+v",
+				tc.link,
+				tc.wantStatus,
+				tc.wantError,
+				got,
+			)
+		}
+	}
+
+	if got := records["example.com/private"]; !got.Disallowed || got.Attempts != 0 {
+		t.Errorf("Test 'TestCrawlWithFakeFetcher' FAILED: the disallowed page was not skipped: got %+v", got)
+	}
+
+	wantHops := []RedirectHop{{URL: "https://example.com/old", Status: http.StatusMovedPermanently}}
+	if got := records["example.com/old"]; !reflect.DeepEqual(got.Redirects, wantHops) || got.RedirectsTo != "example.com/new" {
+		t.Errorf("Test 'TestCrawlWithFakeFetcher' FAILED: unexpected redirect chain for /old: got %+v", got)
+	}
+
+	requests := fetcher.Requests()
+
+	if got := countRequests(requests, "GET https://example.com/robots.txt"); got != 1 {
+		t.Errorf("Test 'TestCrawlWithFakeFetcher' FAILED: unexpected number of robots.txt requests: want 1, got %d", got)
+	}
+
+	if got := countRequests(requests, "GET https://example.com/private"); got != 0 {
+		t.Errorf("Test 'TestCrawlWithFakeFetcher' FAILED: the disallowed page was requested %d times", got)
+	}
+
+	if got := countRequests(requests, "HEAD https://example.org/"); got != 1 {
+		t.Errorf("Test 'TestCrawlWithFakeFetcher' FAILED: unexpected number of external link checks: want 1, got %d", got)
+	}
+}
+
+func TestHTTPFetcher(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.RedirectHandler("/destination", http.StatusFound))
+	defer server.Close()
+
+	client := NewHTTPClient(DefaultTimeout)
+	fetcher := NewHTTPFetcher(client)
+
+	request, err := http.NewRequestWithContext(context.Background(), http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatalf("Test 'TestHTTPFetcher' FAILED: unexpected error creating the request: %v", err)
+	}
+
+	resp, err := fetcher.Fetch(request)
+	if err != nil {
+		t.Fatalf("Test 'TestHTTPFetcher' FAILED: unexpected error fetching the URL: %v", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusFound || resp.Header.Get("Location") != "/destination" {
+		t.Errorf("Test 'TestHTTPFetcher' FAILED: the redirect was followed: got status %d", resp.StatusCode)
+	}
+
+	if client.CheckRedirect != nil {
+		t.Errorf("Test 'TestHTTPFetcher' FAILED: the redirect policy of the given client was changed")
+	}
+}
+
+func countRequests(requests []string, request string) int {
+	count := 0
+
+	for _, got := range slices.All(requests) {
+		if got == request {
+			count++
+		}
+	}
+
+	return count
+}
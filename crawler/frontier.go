@@ -0,0 +1,220 @@
+package crawler
+
+import (
+	"cmp"
+	"container/heap"
+	"context"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// frontier is the queue of internal pages waiting to be fetched. Pages are
+// deduplicated before they are pushed so every page is fetched at most once.
+//
+// The frontier gives a breadth-first crawl: pages are popped in order of their
+// depth, and in the order in which they were pushed for pages of the same depth.
+// A page is only popped once all the pages two or more levels shallower have
+// been fetched so that every page is found at its shallowest depth before it is
+// fetched. A page in flight can only find pages one level deeper than itself,
+// so the workers move on to the next depth as soon as the queue of the current
+// depth is empty instead of waiting for its last pages in flight.
+//
+// The frontier also keeps track of the pages that are being fetched (in flight)
+// as these may push more pages. The crawl is finished when there are no queued
+// pages and no pages in flight, or when the frontier is stopped.
+type frontier struct {
+	mu       sync.Mutex
+	cond     *sync.Cond
+	queue    taskQueue
+	inFlight map[string]task
+	nextSeq  uint64
+	stopped  bool
+}
+
+func newFrontier() *frontier {
+	f := frontier{
+		mu:       sync.Mutex{},
+		cond:     nil,
+		queue:    make(taskQueue, 0),
+		inFlight: make(map[string]task),
+		nextSeq:  0,
+		stopped:  false,
+	}
+
+	f.cond = sync.NewCond(&f.mu)
+
+	return &f
+}
+
+// push adds the tasks to the queue.
+func (f *frontier) push(tasks ...task) {
+	if len(tasks) == 0 {
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for ind := range len(tasks) {
+		heap.Push(&f.queue, queuedTask{task: tasks[ind], seq: f.nextSeq})
+		f.nextSeq++
+	}
+
+	f.cond.Broadcast()
+}
+
+// pop removes the next task from the queue and marks it as in flight.
+// It blocks while the next task must wait for much shallower pages in flight, or
+// while the queue is empty but other tasks are still in flight.
+// It returns false when the frontier is exhausted or stopped, or when the context
+// is cancelled.
+func (f *frontier) pop(ctx context.Context) (task, bool) {
+	// Wake up the waiting workers when the context is cancelled.
+	stop := context.AfterFunc(ctx, func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		f.cond.Broadcast()
+	})
+	defer stop()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for !f.ready() && len(f.inFlight) > 0 && !f.stopped && ctx.Err() == nil {
+		f.cond.Wait()
+	}
+
+	if len(f.queue) == 0 || f.stopped || ctx.Err() != nil {
+		return task{}, false
+	}
+
+	next := heap.Pop(&f.queue).(queuedTask).task
+
+	f.inFlight[next.NormalisedURL] = next
+
+	return next, true
+}
+
+// ready evaluates to true if the next task in the queue can be popped, which is
+// when no task in flight could still find the next task at a shallower depth.
+// The caller must hold the lock.
+func (f *frontier) ready() bool {
+	if len(f.queue) == 0 {
+		return false
+	}
+
+	next := f.queue[0].task
+
+	for _, inFlight := range maps.All(f.inFlight) {
+		if inFlight.Depth < next.Depth-1 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// done marks the in-flight task as complete. Any links found on the page
+// should be pushed before the task is marked as done.
+func (f *frontier) done(normalisedURL string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.inFlight, normalisedURL)
+
+	// Wake up the waiting workers as the next task may now be ready, or the
+	// frontier may now be exhausted.
+	f.cond.Broadcast()
+}
+
+// stop stops the frontier so that no more tasks are popped. The queued tasks are
+// kept so that they are saved with the state of the crawl.
+func (f *frontier) stop() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.stopped = true
+
+	f.cond.Broadcast()
+}
+
+// pending returns the number of queued and in-flight tasks.
+func (f *frontier) pending() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return len(f.queue) + len(f.inFlight)
+}
+
+// queued returns the number of queued tasks.
+func (f *frontier) queued() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return len(f.queue)
+}
+
+// snapshot returns copies of the queued tasks, in the order in which they
+// would be popped, and the in-flight tasks.
+func (f *frontier) snapshot() ([]task, map[string]task) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	sorted := slices.SortedFunc(slices.Values(f.queue), compareQueuedTasks)
+
+	queue := make([]task, len(sorted))
+
+	for ind := range len(sorted) {
+		queue[ind] = sorted[ind].task
+	}
+
+	return queue, maps.Clone(f.inFlight)
+}
+
+// queuedTask is a task in the frontier's queue. The sequence number
+// keeps the order of the tasks of the same depth.
+type queuedTask struct {
+	task task
+	seq  uint64
+}
+
+func compareQueuedTasks(a, b queuedTask) int {
+	if n := cmp.Compare(a.task.Depth, b.task.Depth); n != 0 {
+		return n
+	}
+
+	return cmp.Compare(a.seq, b.seq)
+}
+
+// taskQueue is a priority queue of tasks implementing heap.Interface.
+type taskQueue []queuedTask
+
+func (q taskQueue) Len() int {
+	return len(q)
+}
+
+func (q taskQueue) Less(i, j int) bool {
+	return compareQueuedTasks(q[i], q[j]) < 0
+}
+
+func (q taskQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+}
+
+func (q *taskQueue) Push(x any) {
+	*q = append(*q, x.(queuedTask))
+}
+
+func (q *taskQueue) Pop() any {
+	old := *q
+	last := len(old) - 1
+	item := old[last]
+
+	// Release the reference to the task so that it can be garbage collected.
+	old[last] = queuedTask{}
+	*q = old[:last]
+
+	return item
+}
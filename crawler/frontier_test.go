@@ -0,0 +1,149 @@
+package crawler
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFrontier(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	testFrontier := newFrontier()
+
+	seed := task{RawURL: "https://example.org", NormalisedURL: "example.org", Depth: 0, FromSitemap: false}
+	testFrontier.push(seed)
+
+	got, ok := testFrontier.pop(ctx)
+	if !ok || got != seed {
+		t.Fatalf("Test 'TestFrontier' FAILED: unexpected task popped: want %v, got %v", seed, got)
+	}
+
+	// The queue is empty but the seed is still in flight so the next pop
+	// must wait until the seed's links are pushed.
+	links := []task{
+		{RawURL: "https://example.org/blog", NormalisedURL: "example.org/blog", Depth: 1, FromSitemap: false},
+		{RawURL: "https://example.org/about", NormalisedURL: "example.org/about", Depth: 1, FromSitemap: false},
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		testFrontier.push(links...)
+		testFrontier.done(seed.NormalisedURL)
+	}()
+
+	for ind := range len(links) {
+		got, ok := testFrontier.pop(ctx)
+		if !ok || got != links[ind] {
+			t.Fatalf(
+				"Test 'TestFrontier' FAILED: unexpected task popped (breadth-first order): want %v, got %v",
+				links[ind],
+				got,
+			)
+		}
+	}
+
+	// Both links are in flight. The frontier is exhausted once both are done.
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+
+		for ind := range len(links) {
+			testFrontier.done(links[ind].NormalisedURL)
+		}
+	}()
+
+	if _, ok := testFrontier.pop(ctx); ok {
+		t.Fatalf("Test 'TestFrontier' FAILED: unexpected task popped from an exhausted frontier")
+	}
+
+	if pending := testFrontier.pending(); pending != 0 {
+		t.Errorf("Test 'TestFrontier' FAILED: unexpected number of pending tasks: want 0, got %d", pending)
+	} else {
+		t.Logf("Test 'TestFrontier' PASSED: the frontier was exhausted")
+	}
+}
+
+func TestFrontierCancelled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	testFrontier := newFrontier()
+
+	seed := task{RawURL: "https://example.org", NormalisedURL: "example.org", Depth: 0, FromSitemap: false}
+	testFrontier.push(seed)
+
+	if _, ok := testFrontier.pop(ctx); !ok {
+		t.Fatalf("Test 'TestFrontierCancelled' FAILED: unable to pop the seed from the frontier")
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+
+	// The seed is never marked as done so only the cancellation can
+	// unblock the pop.
+	if _, ok := testFrontier.pop(ctx); ok {
+		t.Fatalf("Test 'TestFrontierCancelled' FAILED: unexpected task popped after the context was cancelled")
+	}
+
+	if pending := testFrontier.pending(); pending != 1 {
+		t.Errorf("Test 'TestFrontierCancelled' FAILED: unexpected number of pending tasks: want 1, got %d", pending)
+	} else {
+		t.Logf("Test 'TestFrontierCancelled' PASSED: the in-flight task was kept after the cancellation")
+	}
+}
+
+func TestFrontierDepthBarrier(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	testFrontier := newFrontier()
+
+	page := func(path string, depth int) task {
+		return task{RawURL: "https://example.org" + path, NormalisedURL: "example.org" + path, Depth: depth, FromSitemap: false}
+	}
+
+	seed := page("", 0)
+	testFrontier.push(seed)
+
+	if _, ok := testFrontier.pop(ctx); !ok {
+		t.Fatalf("Test 'TestFrontierDepthBarrier' FAILED: unable to pop the seed from the frontier")
+	}
+
+	// The pages one level deeper than the seed are popped while the seed is
+	// still in flight as the seed can not find them at a shallower depth.
+	blog := page("/blog", 1)
+	testFrontier.push(blog)
+
+	if got, ok := testFrontier.pop(ctx); !ok || got != blog {
+		t.Fatalf("Test 'TestFrontierDepthBarrier' FAILED: unexpected task popped while the seed is in flight: want %v, got %v", blog, got)
+	}
+
+	// A page two levels deeper than the seed waits for the seed as the seed
+	// may still link to it.
+	post := page("/blog/post", 2)
+	testFrontier.push(post)
+
+	popped := make(chan task)
+
+	go func() {
+		got, _ := testFrontier.pop(ctx)
+		popped <- got
+	}()
+
+	select {
+	case got := <-popped:
+		t.Fatalf("Test 'TestFrontierDepthBarrier' FAILED: unexpected task popped while the seed is in flight: got %v", got)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	testFrontier.done(seed.NormalisedURL)
+
+	if got := <-popped; got != post {
+		t.Errorf("Test 'TestFrontierDepthBarrier' FAILED: unexpected task popped after the seed was done: want %v, got %v", post, got)
+	} else {
+		t.Logf("Test 'TestFrontierDepthBarrier' PASSED: the task waited for the pages two levels shallower")
+	}
+}
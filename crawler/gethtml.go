@@ -0,0 +1,110 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// fetchInfo is the metadata of the response received when retrieving a page.
+// The metadata is from the last attempt if the page was retrieved more than once.
+type fetchInfo struct {
+	attempts     int
+	statusCode   int
+	contentType  string
+	size         int64
+	latency      time.Duration
+	finalURL     string
+	redirects    []RedirectHop
+	redirectLoop bool
+	stopped      string
+	robotsTags   []string
+}
+
+// getHTML retrieves the HTML document from the URL along with the metadata of the
+// response. The metadata is also returned when the document could not be retrieved.
+// The latency is the time taken to receive the whole response and the size is the
+// number of bytes read from its body; the body is not read when the status is bad or
+// the response is not an HTML document so its size is then 0. Redirects are followed
+// up to the maximum number of redirects and every hop is recorded in the metadata.
+// A redirect to a URL that the crawler would not request is not followed; the
+// metadata then records the target of the redirect and the reason.
+func (c *Crawler) getHTML(ctx context.Context, rawURL string) (string, fetchInfo, error) {
+	info := fetchInfo{
+		attempts:     0,
+		statusCode:   0,
+		contentType:  "",
+		size:         0,
+		latency:      0,
+		finalURL:     rawURL,
+		redirects:    nil,
+		redirectLoop: false,
+		stopped:      "",
+		robotsTags:   nil,
+	}
+
+	start := time.Now()
+
+	resp, chain, err := c.do(ctx, http.MethodGet, rawURL, c.checkRedirectHop)
+
+	info.redirects = chain.hops
+	info.redirectLoop = chain.loop
+	info.stopped = chain.stopped
+
+	if err != nil {
+		if chain.stopped != "" {
+			info.finalURL = chain.finalURL
+		}
+
+		info.latency = time.Since(start)
+
+		return "", info, err
+	}
+
+	defer resp.Body.Close()
+
+	info.finalURL = chain.finalURL
+	info.statusCode = resp.StatusCode
+	info.contentType = resp.Header.Get("content-type")
+	info.robotsTags = resp.Header.Values("X-Robots-Tag")
+	info.latency = time.Since(start)
+
+	if resp.StatusCode >= 400 {
+		return "", info, &statusError{
+			url:        info.finalURL,
+			statusCode: resp.StatusCode,
+			status:     resp.Status,
+			retryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		}
+	}
+
+	if !strings.Contains(info.contentType, "text/html") {
+		return "", info, fmt.Errorf("unexpected content type received: want text/html, got %s", info.contentType)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+
+	info.size = int64(len(data))
+	info.latency = time.Since(start)
+
+	if err != nil {
+		return "", info, fmt.Errorf("error reading the data from the response: %w", err)
+	}
+
+	return string(data), info, nil
+}
+
+// statusError is returned when a bad status is received from the server.
+type statusError struct {
+	url        string
+	statusCode int
+	status     string
+	retryAfter time.Duration
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("received a bad status from %s: (%d) %s", e.url, e.statusCode, e.status)
+}
@@ -0,0 +1,182 @@
+package crawler
+
+import (
+	"log/slog"
+	"time"
+
+	"codeflow.dananglin.me.uk/apollo/web-crawler/internal/scope"
+)
+
+// Hooks observes the crawl as it progresses. The hooks are called from the
+// crawler's workers so they may be called concurrently and must be safe for
+// concurrent use. A worker waits for its hooks to return so they should not
+// block. Embed NoHooks to only implement some of the hooks.
+type Hooks interface {
+	// OnPageFetched is called when an internal page has been fetched.
+	OnPageFetched(event PageFetchedEvent)
+
+	// OnLinkDiscovered is called for every link found on a fetched page,
+	// including the links to pages that were already found and the nofollow
+	// links that are not followed.
+	OnLinkDiscovered(event LinkDiscoveredEvent)
+
+	// OnSkipped is called when a page is found that will not be fetched.
+	OnSkipped(event SkippedEvent)
+
+	// OnError is called when a page could not be fetched or processed.
+	OnError(event ErrorEvent)
+}
+
+// PageFetchedEvent describes an internal page that has been fetched. If the page
+// redirected then the redirect chain is included and FinalURL is the destination
+// of the chain, otherwise FinalURL is the URL of the page.
+type PageFetchedEvent struct {
+	URL         string
+	FinalURL    string
+	Seed        string
+	Depth       int
+	Status      int
+	ContentType string
+	Size        int64
+	Duration    time.Duration
+	Attempts    int
+	Redirects   []RedirectHop
+}
+
+// LinkDiscoveredEvent describes a link found on a fetched page. The depth is the
+// depth of the linked page and LinkType is its type within the crawl's scope.
+// NoFollow is true if the link is marked with rel="nofollow" or if the page has
+// the nofollow directive.
+type LinkDiscoveredEvent struct {
+	URL        string
+	Source     string
+	AnchorText string
+	NoFollow   bool
+	LinkType   string
+	Seed       string
+	Depth      int
+}
+
+// SkipReason is the reason why a page is not fetched.
+type SkipReason string
+
+const (
+	SkipReasonDisallowed SkipReason = "disallowed"
+	SkipReasonExcluded   SkipReason = "excluded"
+	SkipReasonMaxDepth   SkipReason = "max-depth"
+	SkipReasonNofollow   SkipReason = "nofollow"
+	SkipReasonLimit      SkipReason = "limit"
+)
+
+// SkippedEvent describes a page that will not be fetched. Pages are skipped when
+// they are disallowed by robots.txt, excluded by the include and exclude patterns,
+// beyond the maximum depth, linked with rel="nofollow" when the nofollow directives
+// are respected, or found after one of the crawl's limits was reached. Skipped
+// pages are reported once, when they are first found, except for the nofollow
+// links which are reported every time. External pages are only reported when
+// they are found after the limit of external links was reached.
+type SkippedEvent struct {
+	URL    string
+	Source string
+	Seed   string
+	Depth  int
+	Reason SkipReason
+}
+
+// ErrorEvent describes an error that occurred while fetching or processing a page.
+// The status and the number of attempts are only set when the page could not be fetched.
+type ErrorEvent struct {
+	URL      string
+	Depth    int
+	Status   int
+	Attempts int
+	Err      error
+}
+
+// NoHooks implements Hooks with hooks that do nothing.
+type NoHooks struct{}
+
+func (NoHooks) OnPageFetched(PageFetchedEvent) {}
+
+func (NoHooks) OnLinkDiscovered(LinkDiscoveredEvent) {}
+
+func (NoHooks) OnSkipped(SkippedEvent) {}
+
+func (NoHooks) OnError(ErrorEvent) {}
+
+// hookList calls every registered Hooks in the order in which they were registered.
+type hookList []Hooks
+
+func (l hookList) OnPageFetched(event PageFetchedEvent) {
+	for _, hooks := range l {
+		hooks.OnPageFetched(event)
+	}
+}
+
+func (l hookList) OnLinkDiscovered(event LinkDiscoveredEvent) {
+	for _, hooks := range l {
+		hooks.OnLinkDiscovered(event)
+	}
+}
+
+func (l hookList) OnSkipped(event SkippedEvent) {
+	for _, hooks := range l {
+		hooks.OnSkipped(event)
+	}
+}
+
+func (l hookList) OnError(event ErrorEvent) {
+	for _, hooks := range l {
+		hooks.OnError(event)
+	}
+}
+
+// skipReason returns the reason why the page found in a link is not fetched, or
+// an empty reason if the page is scheduled for fetching or is a recorded external
+// page. This must be called after the visit has been recorded. The caller must
+// hold the lock.
+func (c *Crawler) skipReason(found discovery) SkipReason {
+	stat, exists := c.pages.get(c.canonicalDiscovery(found).task.NormalisedURL)
+
+	switch {
+	case !exists:
+		return SkipReasonLimit
+	case !stat.internal || stat.scheduled:
+		return ""
+	case stat.disallowed:
+		return SkipReasonDisallowed
+	case stat.excluded:
+		return SkipReasonExcluded
+	case c.reachedMaxDepth(stat.depth):
+		return SkipReasonMaxDepth
+	default:
+		return SkipReasonLimit
+	}
+}
+
+// pageError logs an error that occurred while fetching or processing a page
+// and passes it to the hooks.
+func (c *Crawler) pageError(msg, rawURL string, depth int, err error) {
+	c.logger.Warn(msg, slog.String("url", rawURL), slog.Int("depth", depth), slog.Any("error", err))
+	c.hooks.OnError(ErrorEvent{
+		URL:      rawURL,
+		Depth:    depth,
+		Status:   0,
+		Attempts: 0,
+		Err:      err,
+	})
+}
+
+// linkType returns the type of the discovered URL within the crawl's scope.
+func (d discovery) linkType() string {
+	switch {
+	case !d.internal:
+		return string(scope.External)
+	case d.subdomain:
+		return string(scope.Subdomain)
+	case d.scopeHost:
+		return string(scope.ScopeHost)
+	default:
+		return string(scope.Internal)
+	}
+}
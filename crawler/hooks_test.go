@@ -0,0 +1,147 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// recordingHooks records the events passed to the hooks.
+type recordingHooks struct {
+	mu         sync.Mutex
+	fetched    []PageFetchedEvent
+	discovered []LinkDiscoveredEvent
+	skipped    []SkippedEvent
+	errors     []ErrorEvent
+}
+
+func (h *recordingHooks) OnPageFetched(event PageFetchedEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.fetched = append(h.fetched, event)
+}
+
+func (h *recordingHooks) OnLinkDiscovered(event LinkDiscoveredEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.discovered = append(h.discovered, event)
+}
+
+func (h *recordingHooks) OnSkipped(event SkippedEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.skipped = append(h.skipped, event)
+}
+
+func (h *recordingHooks) OnError(event ErrorEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.errors = append(h.errors, event)
+}
+
+func TestHooks(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><body>
+<a href="/about">About</a>
+<a href="/about">About us</a>
+<a href="/missing">Missing</a>
+<a href="/admin">Admin</a>
+<a href="/sponsored" rel="nofollow">Sponsored</a>
+<a href="https://example.org">Example</a>
+</body></html>`))
+	})
+	mux.HandleFunc("/about", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><body><a href="/about/team">Team</a></body></html>`))
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	hooks := &recordingHooks{}
+
+	testCrawler, err := New(
+		[]string{server.URL},
+		WithMaxWorkers(1),
+		WithMaxDepth(1),
+		WithIgnoreRobots(true),
+		WithRespectNofollow(true),
+		WithExclude("/admin"),
+		WithRetries(1, 0, 0),
+		WithHooks(hooks),
+		WithHooks(NoHooks{}),
+	)
+	if err != nil {
+		t.Fatalf("Test 'TestHooks' FAILED: unexpected error creating the crawler: %v", err)
+	}
+
+	if _, err := testCrawler.Run(context.Background()); err != nil {
+		t.Fatalf("Test 'TestHooks' FAILED: unexpected error running the crawl: %v", err)
+	}
+
+	fetched := make([]string, 0, len(hooks.fetched))
+
+	for _, event := range slices.All(hooks.fetched) {
+		fetched = append(fetched, event.URL)
+
+		if event.Status != http.StatusOK || event.Seed != server.URL {
+			t.Errorf("Test 'TestHooks' FAILED: unexpected page fetched event: %+v", event)
+		}
+	}
+
+	if want := []string{server.URL, server.URL + "/about"}; !slices.Equal(fetched, want) {
+		t.Errorf("Test 'TestHooks' FAILED: unexpected pages fetched: want %v, got %v", want, fetched)
+	}
+
+	// The nofollow link is discovered even though it is not followed.
+	if got := len(hooks.discovered); got != 7 {
+		t.Errorf("Test 'TestHooks' FAILED: unexpected number of links discovered: want 7, got %d", got)
+	}
+
+	for _, event := range slices.All(hooks.discovered) {
+		if want := event.URL == server.URL+"/sponsored"; event.NoFollow != want {
+			t.Errorf("Test 'TestHooks' FAILED: unexpected nofollow flag of the discovered link: want %t, got %+v", want, event)
+		}
+	}
+
+	if got := hooks.discovered[len(hooks.discovered)-2]; got.URL != "https://example.org" || got.LinkType != "external" {
+		t.Errorf("Test 'TestHooks' FAILED: unexpected external link discovered: got %+v", got)
+	}
+
+	wantSkipped := map[string]SkipReason{
+		server.URL + "/sponsored":  SkipReasonNofollow,
+		server.URL + "/admin":      SkipReasonExcluded,
+		server.URL + "/about/team": SkipReasonMaxDepth,
+	}
+
+	gotSkipped := make(map[string]SkipReason)
+
+	for _, event := range slices.All(hooks.skipped) {
+		gotSkipped[event.URL] = event.Reason
+	}
+
+	if len(hooks.skipped) != len(wantSkipped) {
+		t.Errorf("Test 'TestHooks' FAILED: unexpected skipped events: want %v, got %+v", wantSkipped, hooks.skipped)
+	}
+
+	for rawURL, want := range wantSkipped {
+		if got := gotSkipped[rawURL]; got != want {
+			t.Errorf("Test 'TestHooks' FAILED: unexpected skip reason for %s: want %q, got %q", rawURL, want, got)
+		}
+	}
+
+	if len(hooks.errors) != 1 || hooks.errors[0].URL != server.URL+"/missing" || hooks.errors[0].Status != http.StatusNotFound {
+		t.Errorf("Test 'TestHooks' FAILED: unexpected error events: got %+v", hooks.errors)
+	}
+}
@@ -0,0 +1,91 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// hostLimiter spaces out the requests sent to each host. It is shared
+// across all workers so that the limits apply to the crawl as a whole
+// regardless of the number of workers.
+type hostLimiter struct {
+	mu          sync.Mutex
+	next        map[string]time.Time
+	minInterval time.Duration
+}
+
+// newHostLimiter returns a new hostLimiter that allows at most requestsPerSecond
+// requests per second to each host, with at least minDelay between consecutive
+// requests to the same host. A value of 0 or less disables the respective limit.
+func newHostLimiter(requestsPerSecond float64, minDelay time.Duration) *hostLimiter {
+	minInterval := max(minDelay, 0)
+
+	if requestsPerSecond > 0 {
+		minInterval = max(minInterval, time.Duration(float64(time.Second)/requestsPerSecond))
+	}
+
+	return &hostLimiter{
+		mu:          sync.Mutex{},
+		next:        make(map[string]time.Time),
+		minInterval: minInterval,
+	}
+}
+
+// interval returns the time that must pass between two requests to a host.
+// This is the larger of the limiter's own interval and the host's crawl delay.
+func (l *hostLimiter) interval(crawlDelay time.Duration) time.Duration {
+	return max(l.minInterval, crawlDelay)
+}
+
+// wait blocks until a request can be sent to the host, making sure that
+// requests to the same host are spaced out by the limiter's interval or by
+// the host's crawl delay, whichever is longer. Each caller reserves its own
+// slot so concurrent workers are queued one after the other. Requests also
+// wait while the host is paused, even when there is no interval. An error is
+// returned if the context is cancelled while waiting.
+func (l *hostLimiter) wait(ctx context.Context, host string, crawlDelay time.Duration) error {
+	interval := l.interval(crawlDelay)
+
+	l.mu.Lock()
+
+	now := time.Now()
+	slot := l.next[host]
+
+	if slot.Before(now) {
+		slot = now
+	}
+
+	if interval > 0 {
+		l.next[host] = slot.Add(interval)
+	}
+
+	l.mu.Unlock()
+
+	if !slot.After(now) {
+		return nil
+	}
+
+	timer := time.NewTimer(time.Until(slot))
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("stopped waiting for %s: %w", host, ctx.Err())
+	}
+}
+
+// pause holds back every request to the host until the delay has passed, such
+// as when the host responded with a Retry-After header. Requests that are
+// already due later are not brought forward.
+func (l *hostLimiter) pause(host string, delay time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if until := time.Now().Add(delay); until.After(l.next[host]) {
+		l.next[host] = until
+	}
+}
@@ -0,0 +1,175 @@
+package crawler
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestHostLimiterInterval(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name              string
+		requestsPerSecond float64
+		minDelay          time.Duration
+		crawlDelay        time.Duration
+		want              time.Duration
+	}{
+		{
+			name: "No limits",
+			want: 0,
+		},
+		{
+			name:              "Requests per second only",
+			requestsPerSecond: 4,
+			want:              250 * time.Millisecond,
+		},
+		{
+			name:     "Minimum delay only",
+			minDelay: time.Second,
+			want:     time.Second,
+		},
+		{
+			name:              "Minimum delay is longer than the requests per second interval",
+			requestsPerSecond: 4,
+			minDelay:          time.Second,
+			want:              time.Second,
+		},
+		{
+			name:              "Requests per second interval is longer than the minimum delay",
+			requestsPerSecond: 0.5,
+			minDelay:          time.Second,
+			want:              2 * time.Second,
+		},
+		{
+			name:              "Crawl delay is the longest",
+			requestsPerSecond: 4,
+			minDelay:          time.Second,
+			crawlDelay:        5 * time.Second,
+			want:              5 * time.Second,
+		},
+	}
+
+	for ind, tc := range slices.All(cases) {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			limiter := newHostLimiter(tc.requestsPerSecond, tc.minDelay)
+
+			got := limiter.interval(tc.crawlDelay)
+			if got != tc.want {
+				t.Errorf(
+					"Test %d - '%s' FAILED: unexpected interval: want %s, got %s",
+					ind+1,
+					tc.name,
+					tc.want,
+					got,
+				)
+			} else {
+				t.Logf(
+					"Test %d - '%s' PASSED: expected interval: got %s",
+					ind+1,
+					tc.name,
+					got,
+				)
+			}
+		})
+	}
+}
+
+func TestHostLimiterWait(t *testing.T) {
+	t.Parallel()
+
+	interval := 20 * time.Millisecond
+	limiter := newHostLimiter(0, interval)
+	ctx := context.Background()
+
+	start := time.Now()
+
+	// Requests to different hosts are not spaced out.
+	for _, host := range slices.All([]string{"example.com", "example.org", "example.net"}) {
+		if err := limiter.wait(ctx, host, 0); err != nil {
+			t.Fatalf("Test 'TestHostLimiterWait' FAILED: unexpected error: %v", err)
+		}
+	}
+
+	if elapsed := time.Since(start); elapsed >= interval {
+		t.Errorf("Test 'TestHostLimiterWait' FAILED: requests to different hosts were delayed by %s", elapsed)
+	}
+
+	start = time.Now()
+
+	for range 3 {
+		if err := limiter.wait(ctx, "example.com", 0); err != nil {
+			t.Fatalf("Test 'TestHostLimiterWait' FAILED: unexpected error: %v", err)
+		}
+	}
+
+	// The first request to example.com was sent before the loop so
+	// all three requests in the loop must wait for their slot.
+	if elapsed := time.Since(start); elapsed < 3*interval-5*time.Millisecond {
+		t.Errorf(
+			"Test 'TestHostLimiterWait' FAILED: requests to the same host were not spaced out: want at least %s, got %s",
+			3*interval,
+			elapsed,
+		)
+	} else {
+		t.Logf("Test 'TestHostLimiterWait' PASSED: requests to the same host were spaced out over %s", elapsed)
+	}
+
+	cancelledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+
+	if err := limiter.wait(cancelledCtx, "example.com", time.Hour); err == nil {
+		t.Errorf("Test 'TestHostLimiterWait' FAILED: expected an error after the context was cancelled")
+	}
+}
+
+func TestHostLimiterPause(t *testing.T) {
+	t.Parallel()
+
+	delay := 50 * time.Millisecond
+	limiter := newHostLimiter(0, 0)
+	ctx := context.Background()
+
+	limiter.pause("example.com", delay)
+
+	start := time.Now()
+
+	// Requests to the other hosts are not paused.
+	if err := limiter.wait(ctx, "example.org", 0); err != nil {
+		t.Fatalf("Test 'TestHostLimiterPause' FAILED: unexpected error: %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed >= delay {
+		t.Errorf("Test 'TestHostLimiterPause' FAILED: the request to another host was delayed by %s", elapsed)
+	}
+
+	// Every request to the paused host waits, even without an interval.
+	for range 2 {
+		if err := limiter.wait(ctx, "example.com", 0); err != nil {
+			t.Fatalf("Test 'TestHostLimiterPause' FAILED: unexpected error: %v", err)
+		}
+
+		if elapsed := time.Since(start); elapsed < delay-5*time.Millisecond {
+			t.Errorf(
+				"Test 'TestHostLimiterPause' FAILED: the request to the paused host was sent after %s, want at least %s",
+				elapsed,
+				delay,
+			)
+		}
+	}
+
+	// A shorter pause does not bring the requests forward.
+	limiter.pause("example.com", time.Hour)
+	limiter.pause("example.com", time.Millisecond)
+
+	cancelledCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+
+	if err := limiter.wait(cancelledCtx, "example.com", 0); err == nil {
+		t.Errorf("Test 'TestHostLimiterPause' FAILED: the longer pause of the host was shortened")
+	}
+}
@@ -0,0 +1,117 @@
+package crawler
+
+import "log/slog"
+
+// StopReason is the reason why the crawl stopped discovering new pages, or why
+// it was halted by one of its stop conditions.
+type StopReason string
+
+const (
+	StopReasonCompleted        StopReason = "completed"
+	StopReasonMaxPages         StopReason = "max-pages"
+	StopReasonMaxExternalLinks StopReason = "max-external-links"
+	StopReasonMaxDuration      StopReason = "max-duration"
+	StopReasonMaxErrors        StopReason = "max-errors"
+	StopReasonMaxBytes         StopReason = "max-bytes"
+	StopReasonInterrupted      StopReason = "interrupted"
+)
+
+// description returns a human readable description of the stop reason.
+func (r StopReason) description() string {
+	switch r {
+	case StopReasonMaxPages:
+		return "the maximum number of internal pages was reached"
+	case StopReasonMaxExternalLinks:
+		return "the maximum number of external links was reached"
+	case StopReasonMaxDuration:
+		return "the maximum duration of the crawl was reached"
+	case StopReasonMaxErrors:
+		return "the maximum number of fetch errors was reached"
+	case StopReasonMaxBytes:
+		return "the maximum number of bytes downloaded was reached"
+	case StopReasonInterrupted:
+		return "the crawl was interrupted"
+	default:
+		return "all reachable pages were crawled"
+	}
+}
+
+// admit evaluates whether a page can be recorded within the crawl's limits.
+// New external links are counted against the external link limit and internal
+// pages are counted against the page limit when they are scheduled for fetching.
+// The first limit to be reached stops the crawl; after that no new pages are
+// admitted although the pages already in the frontier are still fetched.
+// The caller must hold the lock.
+func (c *Crawler) admit(stat pageStat, exists bool) bool {
+	if c.stopReason != StopReasonCompleted {
+		return false
+	}
+
+	switch {
+	case !exists && !stat.internal:
+		if c.maxExternal > 0 && c.externalLinks >= c.maxExternal {
+			c.stopReason = StopReasonMaxExternalLinks
+
+			return false
+		}
+
+		c.externalLinks++
+	case c.fetchable(stat) && !stat.scheduled:
+		if c.maxPages > 0 && c.scheduledPages >= c.maxPages {
+			c.stopReason = StopReasonMaxPages
+
+			return false
+		}
+
+		c.scheduledPages++
+	}
+
+	return true
+}
+
+// fetchable evaluates to true if the recorded page is an internal page that
+// the crawler is allowed to fetch.
+func (c *Crawler) fetchable(stat pageStat) bool {
+	return stat.internal && !stat.disallowed && !stat.excluded && !stat.nofollowOnly && !c.reachedMaxDepth(stat.depth)
+}
+
+// reachedMaxDepth evaluates to true if pages found at the given depth
+// are beyond the maximum crawl depth.
+func (c *Crawler) reachedMaxDepth(depth int) bool {
+	return c.maxDepth > 0 && depth > c.maxDepth
+}
+
+// checkStopConditions halts the crawl if the fetch errors or the downloaded bytes
+// have reached their limits. The caller must hold the lock.
+func (c *Crawler) checkStopConditions() {
+	switch {
+	case c.maxErrors > 0 && c.fetchErrors >= c.maxErrors:
+		c.halt(StopReasonMaxErrors)
+	case c.maxBytes > 0 && c.fetchedBytes >= c.maxBytes:
+		c.halt(StopReasonMaxBytes)
+	}
+}
+
+// halt stops the crawl because one of its stop conditions was reached. Unlike the
+// page limits, which only stop the crawl from discovering new pages, the stop
+// conditions stop the workers from taking any more pages from the frontier. The
+// pages that are being fetched are completed so that the crawl ends cleanly.
+// Only the first stop condition is recorded. The caller must hold the lock.
+func (c *Crawler) halt(reason StopReason) {
+	if c.haltReason != "" {
+		return
+	}
+
+	c.haltReason = reason
+	c.frontier.stop()
+
+	c.logger.Info("stop condition reached", slog.String("reason", string(reason)))
+}
+
+// halted evaluates to true if the crawl was halted by one of its stop conditions.
+func (c *Crawler) halted() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.haltReason != ""
+}
@@ -0,0 +1,451 @@
+package crawler
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"codeflow.dananglin.me.uk/apollo/web-crawler/crawler/crawlertest"
+)
+
+func TestPageBudget(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name             string
+		maxPages         int
+		maxExternalLinks int
+		internal         int
+		external         int
+		wantPages        int
+		wantExternal     int
+		wantStopReason   StopReason
+	}{
+		{
+			name:             "Within the limits",
+			maxPages:         10,
+			maxExternalLinks: 10,
+			internal:         5,
+			external:         5,
+			wantPages:        5,
+			wantExternal:     5,
+			wantStopReason:   StopReasonCompleted,
+		},
+		{
+			name:             "Maximum number of pages reached",
+			maxPages:         10,
+			maxExternalLinks: 0,
+			internal:         50,
+			external:         0,
+			wantPages:        10,
+			wantExternal:     0,
+			wantStopReason:   StopReasonMaxPages,
+		},
+		{
+			name:             "No page limit",
+			maxPages:         0,
+			maxExternalLinks: 0,
+			internal:         50,
+			external:         5,
+			wantPages:        50,
+			wantExternal:     5,
+			wantStopReason:   StopReasonCompleted,
+		},
+		{
+			name:             "Negative page limit",
+			maxPages:         -1,
+			maxExternalLinks: 0,
+			internal:         20,
+			external:         0,
+			wantPages:        20,
+			wantExternal:     0,
+			wantStopReason:   StopReasonCompleted,
+		},
+		{
+			name:             "Maximum number of external links reached",
+			maxPages:         100,
+			maxExternalLinks: 3,
+			internal:         0,
+			external:         50,
+			wantPages:        0,
+			wantExternal:     3,
+			wantStopReason:   StopReasonMaxExternalLinks,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			testCrawler, err := New(
+				[]string{"https://example.com"},
+				WithMaxWorkers(1),
+				WithMaxPages(tc.maxPages),
+				WithMaxExternalLinks(tc.maxExternalLinks),
+				WithIgnoreRobots(true),
+				WithRetries(1, 0, 0),
+			)
+			if err != nil {
+				t.Fatalf("Test %q FAILED: unexpected error creating the crawler: %v", tc.name, err)
+			}
+
+			// Record the pages concurrently to make sure that the limits are never overshot.
+			var waitGroup sync.WaitGroup
+
+			for ind := range tc.internal + tc.external {
+				waitGroup.Add(1)
+
+				go func() {
+					defer waitGroup.Done()
+
+					host := "example.com"
+					if ind >= tc.internal {
+						host = "external-" + strconv.Itoa(ind) + ".example.net"
+					}
+
+					link := host + "/page-" + strconv.Itoa(ind)
+
+					testCrawler.addPageVisit(discovery{
+						task: task{
+							RawURL:        "https://" + link,
+							NormalisedURL: link,
+							Depth:         1,
+							FromSitemap:   false,
+						},
+						internal:   ind < tc.internal,
+						disallowed: false,
+					})
+				}()
+			}
+
+			waitGroup.Wait()
+
+			if got := testCrawler.frontier.pending(); got != tc.wantPages {
+				t.Errorf("Test %q FAILED: unexpected number of pages queued: want %d, got %d", tc.name, tc.wantPages, got)
+			}
+
+			if got := testCrawler.externalLinks; got != tc.wantExternal {
+				t.Errorf("Test %q FAILED: unexpected number of external links: want %d, got %d", tc.name, tc.wantExternal, got)
+			}
+
+			if got := testCrawler.pages.len(); got != tc.wantPages+tc.wantExternal {
+				t.Errorf(
+					"Test %q FAILED: unexpected number of recorded pages: want %d, got %d",
+					tc.name,
+					tc.wantPages+tc.wantExternal,
+					got,
+				)
+			}
+
+			if testCrawler.stopReason != tc.wantStopReason {
+				t.Errorf(
+					"Test %q FAILED: unexpected stop reason: want %s, got %s",
+					tc.name,
+					tc.wantStopReason,
+					testCrawler.stopReason,
+				)
+			}
+
+			// A negative limit is reported as no limit.
+			if got := testCrawler.result().Summary.MaxPages; got != max(tc.maxPages, 0) {
+				t.Errorf("Test %q FAILED: unexpected page limit in the summary: want %d, got %d", tc.name, max(tc.maxPages, 0), got)
+			}
+		})
+	}
+}
+
+func TestStopConditions(t *testing.T) {
+	t.Parallel()
+
+	// The home page links to ten pages; the odd pages are missing.
+	const pageBody = `<html><body></body></html>`
+
+	var links strings.Builder
+
+	for ind := range 10 {
+		links.WriteString(`<a href="/page-` + strconv.Itoa(ind) + `">Page</a>`)
+	}
+
+	homeBody := `<html><body>` + links.String() + `</body></html>`
+
+	newFetcher := func(delay time.Duration) *crawlertest.Fetcher {
+		fetcher := crawlertest.NewFetcher()
+		fetcher.AddHTML("https://example.com", homeBody)
+
+		for ind := 0; ind < 10; ind += 2 {
+			fetcher.Add("https://example.com/page-"+strconv.Itoa(ind), crawlertest.Response{
+				Status: 200,
+				Header: map[string][]string{"Content-Type": {"text/html"}},
+				Body:   pageBody,
+				Delay:  delay,
+				Err:    nil,
+			})
+		}
+
+		return fetcher
+	}
+
+	cases := []struct {
+		name             string
+		option           Option
+		delay            time.Duration
+		wantStopReason   StopReason
+		wantPagesFetched int
+		wantErrors       int
+	}{
+		{
+			name:             "Maximum number of errors reached",
+			option:           WithMaxErrors(2),
+			delay:            0,
+			wantStopReason:   StopReasonMaxErrors,
+			wantPagesFetched: 5,
+			wantErrors:       2,
+		},
+		{
+			name:             "Maximum number of bytes reached",
+			option:           WithMaxBytes(int64(len(homeBody) + 1)),
+			delay:            0,
+			wantStopReason:   StopReasonMaxBytes,
+			wantPagesFetched: 2,
+			wantErrors:       0,
+		},
+		{
+			name:             "Maximum duration reached",
+			option:           WithMaxDuration(50 * time.Millisecond),
+			delay:            200 * time.Millisecond,
+			wantStopReason:   StopReasonMaxDuration,
+			wantPagesFetched: 2,
+			wantErrors:       0,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			testCrawler, err := New(
+				[]string{"https://example.com"},
+				WithMaxWorkers(1),
+				WithMaxPages(100),
+				WithIgnoreRobots(true),
+				WithRetries(1, 0, 0),
+				WithFetcher(newFetcher(tc.delay)),
+				tc.option,
+			)
+			if err != nil {
+				t.Fatalf("Test %q FAILED: unexpected error creating the crawler: %v", tc.name, err)
+			}
+
+			result, err := testCrawler.Run(context.Background())
+			if err != nil {
+				t.Fatalf("Test %q FAILED: unexpected error running the crawl: %v", tc.name, err)
+			}
+
+			summary := result.Summary
+
+			if summary.StopReason != tc.wantStopReason {
+				t.Errorf("Test %q FAILED: unexpected stop reason: want %s, got %s", tc.name, tc.wantStopReason, summary.StopReason)
+			}
+
+			// The page that was being fetched when the crawl was halted is completed.
+			if summary.PagesFetched != tc.wantPagesFetched || summary.Errors != tc.wantErrors {
+				t.Errorf(
+					"Test %q FAILED: unexpected totals: want %d pages fetched and %d errors, got %d pages fetched and %d errors",
+					tc.name,
+					tc.wantPagesFetched,
+					tc.wantErrors,
+					summary.PagesFetched,
+					summary.Errors,
+				)
+			}
+
+			if !result.Partial {
+				t.Errorf("Test %q FAILED: the result of the halted crawl is not partial", tc.name)
+			}
+		})
+	}
+}
+
+func TestMaxDurationDuringExternalLinkChecks(t *testing.T) {
+	t.Parallel()
+
+	var links strings.Builder
+
+	fetcher := crawlertest.NewFetcher()
+
+	for ind := range 10 {
+		rawURL := "https://external-" + strconv.Itoa(ind) + ".example.net/"
+
+		links.WriteString(`<a href="` + rawURL + `">External</a>`)
+		fetcher.Add(rawURL, crawlertest.Response{
+			Status: 200,
+			Header: map[string][]string{"Content-Type": {"text/html"}},
+			Body:   `<html><body></body></html>`,
+			Delay:  100 * time.Millisecond,
+			Err:    nil,
+		})
+	}
+
+	fetcher.AddHTML("https://example.com", `<html><body>`+links.String()+`</body></html>`)
+
+	testCrawler, err := New(
+		[]string{"https://example.com"},
+		WithMaxWorkers(1),
+		WithExternalWorkers(1),
+		WithIgnoreRobots(true),
+		WithRetries(1, 0, 0),
+		WithExternalLinkCheck(true),
+		WithMaxDuration(250*time.Millisecond),
+		WithFetcher(fetcher),
+	)
+	if err != nil {
+		t.Fatalf("Test 'TestMaxDurationDuringExternalLinkChecks' FAILED: unexpected error creating the crawler: %v", err)
+	}
+
+	start := time.Now()
+
+	result, err := testCrawler.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Test 'TestMaxDurationDuringExternalLinkChecks' FAILED: unexpected error running the crawl: %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 600*time.Millisecond {
+		t.Errorf(
+			"Test 'TestMaxDurationDuringExternalLinkChecks' FAILED: the external link checks were not stopped: the crawl ran for %s",
+			elapsed,
+		)
+	}
+
+	checked := 0
+
+	for _, record := range result.Records {
+		if record.Checked {
+			checked++
+		}
+	}
+
+	if result.Summary.StopReason != StopReasonMaxDuration || !result.Partial || checked == 0 || checked == 10 {
+		t.Errorf(
+			"Test 'TestMaxDurationDuringExternalLinkChecks' FAILED: unexpected result: want a partial crawl stopped by %s with some links checked, "+
+				"got a crawl stopped by %s (partial %t) with %d links checked",
+			StopReasonMaxDuration,
+			result.Summary.StopReason,
+			result.Partial,
+			checked,
+		)
+	}
+}
+
+func TestMaxDurationDuringSitemaps(t *testing.T) {
+	t.Parallel()
+
+	var index strings.Builder
+
+	fetcher := crawlertest.NewFetcher()
+
+	for ind := range 10 {
+		rawURL := "https://example.com/sitemap-" + strconv.Itoa(ind) + ".xml"
+
+		index.WriteString(`<sitemap><loc>` + rawURL + `</loc></sitemap>`)
+		fetcher.Add(rawURL, crawlertest.Response{
+			Status: 200,
+			Header: map[string][]string{"Content-Type": {"application/xml"}},
+			Body:   `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"></urlset>`,
+			Delay:  100 * time.Millisecond,
+			Err:    nil,
+		})
+	}
+
+	fetcher.AddHTML("https://example.com", `<html><body></body></html>`)
+	fetcher.Add("https://example.com/sitemap.xml", crawlertest.Response{
+		Status: 200,
+		Header: map[string][]string{"Content-Type": {"application/xml"}},
+		Body:   `<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">` + index.String() + `</sitemapindex>`,
+		Delay:  0,
+		Err:    nil,
+	})
+
+	testCrawler, err := New(
+		[]string{"https://example.com"},
+		WithMaxWorkers(1),
+		WithIgnoreRobots(true),
+		WithRetries(1, 0, 0),
+		WithSitemaps(true),
+		WithMaxDuration(150*time.Millisecond),
+		WithFetcher(fetcher),
+	)
+	if err != nil {
+		t.Fatalf("Test 'TestMaxDurationDuringSitemaps' FAILED: unexpected error creating the crawler: %v", err)
+	}
+
+	start := time.Now()
+
+	result, err := testCrawler.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Test 'TestMaxDurationDuringSitemaps' FAILED: unexpected error running the crawl: %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 600*time.Millisecond {
+		t.Errorf("Test 'TestMaxDurationDuringSitemaps' FAILED: the sitemaps were still requested: the crawl ran for %s", elapsed)
+	}
+
+	// The sitemap that was being requested when the crawl was halted is completed.
+	if requests := fetcher.Requests(); len(requests) > 5 {
+		t.Errorf("Test 'TestMaxDurationDuringSitemaps' FAILED: unexpected requests after the crawl was halted: %v", requests)
+	}
+
+	if result.Summary.StopReason != StopReasonMaxDuration {
+		t.Errorf(
+			"Test 'TestMaxDurationDuringSitemaps' FAILED: unexpected stop reason: want %s, got %s",
+			StopReasonMaxDuration,
+			result.Summary.StopReason,
+		)
+	}
+}
+
+func TestMaxBytesOnlyCountsBodiesRead(t *testing.T) {
+	t.Parallel()
+
+	homeBody := `<html><body><a href="/report.pdf">Report</a><a href="/missing">Missing</a></body></html>`
+
+	fetcher := crawlertest.NewFetcher()
+	fetcher.AddHTML("https://example.com", homeBody)
+	fetcher.Add("https://example.com/report.pdf", crawlertest.Response{
+		Status: 200,
+		Header: map[string][]string{"Content-Type": {"application/pdf"}},
+		Body:   strings.Repeat("x", 10000),
+		Delay:  0,
+		Err:    nil,
+	})
+
+	testCrawler, err := New(
+		[]string{"https://example.com"},
+		WithMaxWorkers(1),
+		WithIgnoreRobots(true),
+		WithRetries(1, 0, 0),
+		WithMaxBytes(5000),
+		WithFetcher(fetcher),
+	)
+	if err != nil {
+		t.Fatalf("Test 'TestMaxBytesOnlyCountsBodiesRead' FAILED: unexpected error creating the crawler: %v", err)
+	}
+
+	result, err := testCrawler.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Test 'TestMaxBytesOnlyCountsBodiesRead' FAILED: unexpected error running the crawl: %v", err)
+	}
+
+	// The bodies of the PDF document and of the missing page are not read.
+	if got := result.Summary; got.BytesFetched != int64(len(homeBody)) || got.StopReason != StopReasonCompleted {
+		t.Errorf(
+			"Test 'TestMaxBytesOnlyCountsBodiesRead' FAILED: unexpected summary: want %d bytes and stop reason %s, got %d bytes and stop reason %s",
+			len(homeBody),
+			StopReasonCompleted,
+			got.BytesFetched,
+			got.StopReason,
+		)
+	}
+}
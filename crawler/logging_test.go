@@ -0,0 +1,90 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLogging(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><body><a href="/missing">Missing</a></body></html>`))
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	var logs bytes.Buffer
+
+	testCrawler, err := New(
+		[]string{server.URL},
+		WithMaxWorkers(1),
+		WithIgnoreRobots(true),
+		WithRetries(1, 0, 0),
+		WithLogger(slog.New(slog.NewJSONHandler(&logs, nil))),
+	)
+	if err != nil {
+		t.Fatalf("Test 'TestLogging' FAILED: unexpected error creating the crawler: %v", err)
+	}
+
+	testCrawler.crawl(context.Background())
+
+	type event struct {
+		Level  string `json:"level"`
+		Msg    string `json:"msg"`
+		URL    string `json:"url"`
+		Depth  int    `json:"depth"`
+		Status int    `json:"status"`
+		Error  string `json:"error"`
+	}
+
+	events := make(map[string]event)
+	decoder := json.NewDecoder(&logs)
+
+	for decoder.More() {
+		var got event
+		if err := decoder.Decode(&got); err != nil {
+			t.Fatalf("Test 'TestLogging' FAILED: unexpected error decoding the logs: %v", err)
+		}
+
+		events[got.URL] = got
+	}
+
+	// The debug events are below the default level of the handler.
+	want := map[string]event{
+		server.URL: {
+			Level:  "INFO",
+			Msg:    "page fetched",
+			URL:    server.URL,
+			Depth:  0,
+			Status: http.StatusOK,
+			Error:  "",
+		},
+		server.URL + "/missing": {
+			Level:  "WARN",
+			Msg:    "unable to fetch page",
+			URL:    server.URL + "/missing",
+			Depth:  1,
+			Status: http.StatusNotFound,
+			Error:  events[server.URL+"/missing"].Error,
+		},
+	}
+
+	for rawURL, wantEvent := range want {
+		if got := events[rawURL]; got != wantEvent {
+			t.Errorf("Test 'TestLogging' FAILED: unexpected event for %s: want %+v, got %+v", rawURL, wantEvent, got)
+		}
+	}
+
+	if events[server.URL+"/missing"].Error == "" {
+		t.Errorf("Test 'TestLogging' FAILED: the error of the failed fetch was not logged")
+	}
+}
@@ -0,0 +1,262 @@
+package crawler
+
+import (
+	"log/slog"
+	"time"
+)
+
+// Option configures a crawler created with New.
+type Option func(*config)
+
+// defaultConfig returns the configuration of a crawler created without options.
+func defaultConfig() config {
+	return config{
+		MaxWorkers:         2,
+		ExternalWorkers:    1,
+		MaxPages:           10,
+		MaxExternalLinks:   0,
+		MaxDuration:        0,
+		MaxErrors:          0,
+		MaxBytes:           0,
+		MaxDepth:           0,
+		MaxRedirects:       10,
+		ScopeMode:          "host",
+		ScopeHosts:         nil,
+		UserAgent:          "web-crawler",
+		IgnoreRobots:       false,
+		RespectNofollow:    false,
+		Include:            nil,
+		Exclude:            nil,
+		RequestsPerSecond:  0,
+		MinDelay:           0,
+		MaxAttempts:        3,
+		RetryBaseDelay:     500 * time.Millisecond,
+		RetryMaxDelay:      30 * time.Second,
+		StateFile:          "",
+		CheckpointInterval: 30 * time.Second,
+		Resume:             false,
+		Sitemaps:           false,
+		CheckExternalLinks: false,
+		Logger:             nil,
+		Hooks:              nil,
+		Fetcher:            nil,
+		StoreFile:          "",
+	}
+}
+
+// WithMaxWorkers sets the maximum number of concurrent workers that fetch the pages.
+// The default is 2.
+func WithMaxWorkers(workers int) Option {
+	return func(c *config) {
+		c.MaxWorkers = workers
+	}
+}
+
+// WithExternalWorkers sets the maximum number of concurrent workers that check
+// the external links. The default is 1.
+func WithExternalWorkers(workers int) Option {
+	return func(c *config) {
+		c.ExternalWorkers = workers
+	}
+}
+
+// WithMaxPages sets the maximum number of internal pages to fetch before stopping
+// the crawl. A limit of 0 or less means there is no limit. The default is 10.
+func WithMaxPages(pages int) Option {
+	return func(c *config) {
+		c.MaxPages = pages
+	}
+}
+
+// WithMaxExternalLinks sets the maximum number of unique external links to record
+// before stopping the crawl. A limit of 0 or less means there is no limit, which is
+// the default.
+func WithMaxExternalLinks(links int) Option {
+	return func(c *config) {
+		c.MaxExternalLinks = links
+	}
+}
+
+// WithMaxDuration sets the maximum duration of the crawl. Once it is reached no
+// more pages are fetched, although the pages that are being fetched are completed.
+// A duration of 0 or less means there is no limit, which is the default.
+func WithMaxDuration(duration time.Duration) Option {
+	return func(c *config) {
+		c.MaxDuration = duration
+	}
+}
+
+// WithMaxErrors sets the maximum number of pages that fail to be fetched before the
+// crawl is stopped. A limit of 0 or less means there is no limit, which is the default.
+func WithMaxErrors(errors int) Option {
+	return func(c *config) {
+		c.MaxErrors = errors
+	}
+}
+
+// WithMaxBytes sets the maximum total size in bytes of the fetched pages before the
+// crawl is stopped. Only the bodies of the internal pages are counted; the robots.txt
+// files, the sitemaps and the checked external links are not. A limit of 0 or less
+// means there is no limit, which is the default.
+func WithMaxBytes(bytes int64) Option {
+	return func(c *config) {
+		c.MaxBytes = bytes
+	}
+}
+
+// WithMaxDepth sets the maximum number of clicks away from the seed URLs that the
+// crawler follows links to. A depth of 0 or less means there is no depth limit, which
+// is the default.
+func WithMaxDepth(depth int) Option {
+	return func(c *config) {
+		c.MaxDepth = depth
+	}
+}
+
+// WithMaxRedirects sets the maximum number of redirects followed when fetching a page.
+// The default is 10.
+func WithMaxRedirects(redirects int) Option {
+	return func(c *config) {
+		c.MaxRedirects = redirects
+	}
+}
+
+// WithScope sets the hosts that are crawled. The modes are "host" (the default),
+// "subdomains", "domain" and "hosts". The hosts are crawled in addition to the hosts
+// of the seed URLs when the mode is "hosts". See the scope package for details.
+func WithScope(mode string, hosts ...string) Option {
+	return func(c *config) {
+		c.ScopeMode = mode
+		c.ScopeHosts = hosts
+	}
+}
+
+// WithUserAgent sets the user agent sent with every request. It is also used to
+// select the rules that apply to the crawler from a site's robots.txt file.
+// The default is "web-crawler".
+func WithUserAgent(userAgent string) Option {
+	return func(c *config) {
+		c.UserAgent = userAgent
+	}
+}
+
+// WithIgnoreRobots disables the fetching and enforcement of robots.txt files.
+func WithIgnoreRobots(ignore bool) Option {
+	return func(c *config) {
+		c.IgnoreRobots = ignore
+	}
+}
+
+// WithRespectNofollow stops the crawler from following the links marked with
+// rel="nofollow" and all the links on the pages with the nofollow directive in a
+// robots meta tag or in the X-Robots-Tag header. The links are still recorded.
+func WithRespectNofollow(respect bool) Option {
+	return func(c *config) {
+		c.RespectNofollow = respect
+	}
+}
+
+// WithInclude sets the patterns that internal URLs must match to be crawled.
+// See the filter package for the syntax of the patterns.
+func WithInclude(patterns ...string) Option {
+	return func(c *config) {
+		c.Include = patterns
+	}
+}
+
+// WithExclude sets the patterns of the internal URLs that must not be crawled.
+// See the filter package for the syntax of the patterns.
+func WithExclude(patterns ...string) Option {
+	return func(c *config) {
+		c.Exclude = patterns
+	}
+}
+
+// WithRateLimit sets the maximum number of requests per second sent to each host
+// and the minimum delay between consecutive requests to the same host. There is
+// no limit by default.
+func WithRateLimit(requestsPerSecond float64, minDelay time.Duration) Option {
+	return func(c *config) {
+		c.RequestsPerSecond = requestsPerSecond
+		c.MinDelay = minDelay
+	}
+}
+
+// WithRetries sets the maximum number of attempts made to retrieve a page that fails
+// with a transient error, the delay before the first retry and the maximum delay
+// between two attempts. The defaults are 3 attempts, 500ms and 30s.
+func WithRetries(maxAttempts int, baseDelay, maxDelay time.Duration) Option {
+	return func(c *config) {
+		c.MaxAttempts = maxAttempts
+		c.RetryBaseDelay = baseDelay
+		c.RetryMaxDelay = maxDelay
+	}
+}
+
+// WithStateFile saves the state of the crawl to the file at every interval and when
+// the crawl finishes so that the crawl can be resumed later. The default interval is 30s.
+func WithStateFile(path string, checkpointInterval time.Duration) Option {
+	return func(c *config) {
+		c.StateFile = path
+		c.CheckpointInterval = checkpointInterval
+	}
+}
+
+// WithResume resumes the crawl from the state saved in the state file.
+func WithResume(resume bool) Option {
+	return func(c *config) {
+		c.Resume = resume
+	}
+}
+
+// WithSitemaps crawls the pages listed in the sitemaps of the seed URLs' sites once
+// the links from the seed URLs have been followed. The pages only found in the sitemaps
+// are reported as orphan pages.
+func WithSitemaps(crawl bool) Option {
+	return func(c *config) {
+		c.Sitemaps = crawl
+	}
+}
+
+// WithExternalLinkCheck checks every unique external link once the crawl has finished.
+func WithExternalLinkCheck(check bool) Option {
+	return func(c *config) {
+		c.CheckExternalLinks = check
+	}
+}
+
+// WithLogger sets the logger that the crawl events are logged to.
+// Nothing is logged by default.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *config) {
+		c.Logger = logger
+	}
+}
+
+// WithHooks registers hooks that observe the crawl. This can be repeated to register
+// more than one set of hooks; they are called in the order in which they were registered.
+func WithHooks(hooks Hooks) Option {
+	return func(c *config) {
+		c.Hooks = append(c.Hooks, hooks)
+	}
+}
+
+// WithFetcher sets the fetcher that sends the crawler's HTTP requests. By default
+// the requests are sent by an HTTPFetcher with a client created by NewHTTPClient
+// and the default timeout.
+func WithFetcher(fetcher Fetcher) Option {
+	return func(c *config) {
+		c.Fetcher = fetcher
+	}
+}
+
+// WithStoreFile stores the pages found during the crawl in an embedded database at
+// the path instead of in memory. The file is created if it does not exist and any
+// pages left in it by a previous crawl are removed. The result of the crawl then
+// has no records; the report is written from the store with Crawler.WriteReport.
+// By default the pages are kept in memory.
+func WithStoreFile(path string) Option {
+	return func(c *config) {
+		c.StoreFile = path
+	}
+}
@@ -0,0 +1,118 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// progressRateWindow is the period over which the rate of the crawl is measured.
+const progressRateWindow = 5 * time.Second
+
+// Progress is a snapshot of the progress of the crawl.
+type Progress struct {
+	PagesFetched  int
+	PagesQueued   int
+	Errors        int
+	ExternalLinks int
+	MaxPages      int
+}
+
+// Progress returns a snapshot of the progress of the crawl. The errors are
+// the pages that could not be fetched.
+func (c *Crawler) Progress() Progress {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return Progress{
+		PagesFetched:  c.fetchedPages,
+		PagesQueued:   c.frontier.queued(),
+		Errors:        c.fetchErrors,
+		ExternalLinks: c.externalLinks,
+		MaxPages:      c.maxPages,
+	}
+}
+
+// RunProgress periodically writes the progress of the crawl to the writer until
+// the context is cancelled. If live is true then a single status line is updated
+// in place, which is meant for terminals, otherwise a new line is written every
+// interval. The final progress is always written before RunProgress returns.
+func (c *Crawler) RunProgress(ctx context.Context, writer io.Writer, interval time.Duration, live bool) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	type sample struct {
+		at      time.Time
+		fetched int
+	}
+
+	start := time.Now()
+	samples := []sample{{at: start, fetched: c.Progress().PagesFetched}}
+
+	write := func(now time.Time, final bool) {
+		progress := c.Progress()
+
+		// The rate is measured over the last few seconds so that it
+		// follows the current speed of the crawl without jumping about.
+		for len(samples) > 1 && now.Sub(samples[1].at) >= progressRateWindow {
+			samples = samples[1:]
+		}
+
+		rate := 0.0
+		if elapsed := now.Sub(samples[0].at).Seconds(); elapsed > 0 {
+			rate = float64(progress.PagesFetched-samples[0].fetched) / elapsed
+		}
+
+		samples = append(samples, sample{at: now, fetched: progress.PagesFetched})
+
+		line := progress.line(rate, now.Sub(start))
+
+		switch {
+		case !live:
+			fmt.Fprintln(writer, line)
+		case final:
+			fmt.Fprint(writer, "\r\033[K"+line+"\n")
+		default:
+			fmt.Fprint(writer, "\r\033[K"+line)
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			write(time.Now(), true)
+
+			return
+		case now := <-ticker.C:
+			write(now, false)
+		}
+	}
+}
+
+// line returns the progress as a single line of text.
+func (p Progress) line(rate float64, elapsed time.Duration) string {
+	fetched := fmt.Sprintf("%d pages fetched", p.PagesFetched)
+	if p.MaxPages > 0 {
+		fetched = fmt.Sprintf(
+			"%d/%d pages fetched (%.0f%%)",
+			p.PagesFetched,
+			p.MaxPages,
+			100*float64(p.PagesFetched)/float64(p.MaxPages),
+		)
+	}
+
+	return fmt.Sprintf(
+		"%s, %d queued, %d errors, %d external links, %.1f pages/s, %s elapsed",
+		fetched,
+		p.PagesQueued,
+		p.Errors,
+		p.ExternalLinks,
+		rate,
+		elapsed.Round(time.Second),
+	)
+}
@@ -0,0 +1,61 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestProgress(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><body><a href="/missing">Missing</a><a href="https://example.org">Example</a></body></html>`))
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	testCrawler, err := New(
+		[]string{server.URL},
+		WithMaxWorkers(1),
+		WithIgnoreRobots(true),
+		WithRetries(1, 0, 0),
+	)
+	if err != nil {
+		t.Fatalf("Test 'TestProgress' FAILED: unexpected error creating the crawler: %v", err)
+	}
+
+	testCrawler.crawl(context.Background())
+
+	want := Progress{
+		PagesFetched:  2,
+		PagesQueued:   0,
+		Errors:        1,
+		ExternalLinks: 1,
+		MaxPages:      10,
+	}
+
+	if got := testCrawler.Progress(); got != want {
+		t.Errorf("Test 'TestProgress' FAILED: unexpected progress: want %+v, got %+v", want, got)
+	}
+
+	// The final progress is written when the context is cancelled.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var output bytes.Buffer
+
+	testCrawler.RunProgress(ctx, &output, time.Second, false)
+
+	wantOutput := "2/10 pages fetched (20%), 0 queued, 1 errors, 1 external links, 0.0 pages/s, 0s elapsed\n"
+
+	if got := output.String(); got != wantOutput {
+		t.Errorf("Test 'TestProgress' FAILED: unexpected output: want %q, got %q", wantOutput, got)
+	}
+}
@@ -0,0 +1,213 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+)
+
+var (
+	errTooManyRedirects = errors.New("too many redirects")
+	errRedirectLoop     = errors.New("redirect loop detected")
+	errRedirectStopped  = errors.New("redirect not followed")
+)
+
+// RedirectHop is a single redirect in a redirect chain. The URL is the URL that
+// responded with the redirect status.
+type RedirectHop struct {
+	URL    string `json:"url"`
+	Status int    `json:"status"`
+}
+
+// redirectChain is the redirect chain followed by a request. If the chain was
+// stopped before its last hop then stopped is the reason and the final URL is the
+// target of the redirect that was not followed.
+type redirectChain struct {
+	finalURL string
+	hops     []RedirectHop
+	loop     bool
+	stopped  string
+}
+
+// hopCheck evaluates whether the redirect to the URL can be followed. It returns
+// the reason why the redirect must not be followed or an empty string.
+type hopCheck func(ctx context.Context, rawURL string) string
+
+// do sends a request with the given method to the URL with the crawler's fetcher.
+// Redirects are followed up to the maximum number of redirects and every hop is
+// recorded in the chain. The chain is not followed when it loops back to a URL
+// that was already requested or when the check, if there is one, rejects the
+// target of a hop; the target is then not requested. The caller waits for its
+// turn with the host limiter before the first request and every hop waits for
+// its own turn, with the crawl delay of its host if its robots.txt rules are
+// known. The caller must close the body of the response.
+func (c *Crawler) do(ctx context.Context, method, rawURL string, check hopCheck) (*http.Response, redirectChain, error) {
+	chain := redirectChain{
+		finalURL: rawURL,
+		hops:     nil,
+		loop:     false,
+		stopped:  "",
+	}
+
+	requested := []string{rawURL}
+
+	for {
+		request, err := http.NewRequestWithContext(ctx, method, chain.finalURL, nil)
+		if err != nil {
+			return nil, chain, fmt.Errorf("error creating the HTTP request: %w", err)
+		}
+
+		request.Header.Set("User-Agent", c.userAgent)
+
+		resp, err := c.fetcher.Fetch(request)
+		if err != nil {
+			return nil, chain, fmt.Errorf("error getting the response: %w", err)
+		}
+
+		location := resp.Header.Get("Location")
+		if !isRedirect(resp.StatusCode) || location == "" {
+			return resp, chain, nil
+		}
+
+		resp.Body.Close()
+
+		next, err := request.URL.Parse(location)
+		if err != nil {
+			return nil, chain, fmt.Errorf("error parsing the redirect location %q: %w", location, err)
+		}
+
+		nextURL := next.String()
+
+		chain.hops = append(chain.hops, RedirectHop{URL: chain.finalURL, Status: resp.StatusCode})
+
+		if slices.Contains(requested, nextURL) {
+			chain.loop = true
+
+			return nil, chain, fmt.Errorf("error following the redirect to %s: %w", nextURL, errRedirectLoop)
+		}
+
+		if len(chain.hops) > c.maxRedirects {
+			return nil, chain, fmt.Errorf("error following the redirect to %s: %w", nextURL, errTooManyRedirects)
+		}
+
+		if check != nil {
+			if reason := check(ctx, nextURL); reason != "" {
+				chain.finalURL = nextURL
+				chain.stopped = reason
+
+				return nil, chain, fmt.Errorf("error following the redirect to %s: %w: %s", nextURL, errRedirectStopped, reason)
+			}
+		}
+
+		if err := c.limiter.wait(ctx, next.Host, c.knownCrawlDelay(next)); err != nil {
+			return nil, chain, fmt.Errorf("error waiting to follow the redirect to %s: %w", nextURL, err)
+		}
+
+		requested = append(requested, nextURL)
+		chain.finalURL = nextURL
+	}
+}
+
+// isRedirect returns true if the status code is one of the redirect statuses
+// followed by the crawler.
+func isRedirect(statusCode int) bool {
+	switch statusCode {
+	case http.StatusMovedPermanently,
+		http.StatusFound,
+		http.StatusSeeOther,
+		http.StatusTemporaryRedirect,
+		http.StatusPermanentRedirect:
+		return true
+	default:
+		return false
+	}
+}
+
+// checkRedirectHop evaluates whether the redirect of a page to the URL can be
+// followed. A redirect is not followed to a URL that is out of the crawl's scope,
+// disallowed by the robots.txt rules or excluded by the URL filters as the crawler
+// would not request the URL if it was linked to.
+func (c *Crawler) checkRedirectHop(ctx context.Context, rawURL string) string {
+	target, err := c.discover(ctx, rawURL, "", 0, false)
+
+	switch {
+	case err != nil:
+		return "invalid URL"
+	case !target.internal:
+		return "out of scope"
+	case target.disallowed:
+		return "disallowed by robots.txt"
+	case target.excluded:
+		return "excluded by the URL filters"
+	default:
+		return ""
+	}
+}
+
+// claimPage marks the page as fetched. It returns false if the page was already
+// fetched, for example as the destination of a redirect.
+func (c *Crawler) claimPage(normalisedURL string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stat, _ := c.pages.get(normalisedURL)
+	if stat.fetched {
+		return false
+	}
+
+	stat.fetched = true
+	c.pages.put(normalisedURL, stat)
+
+	return true
+}
+
+// claimRedirectTarget records the final destination of a page that redirected and
+// evaluates whether the links on the destination page should be followed. This is
+// not the case if the destination is out of the crawl's scope, if it was already
+// fetched. Otherwise the destination is recorded as a page fetched through the
+// source page with the metadata of the response. As the destination was reached
+// with the source page's request it is not counted again as a fetched page nor
+// against the page limit.
+func (c *Crawler) claimRedirectTarget(normalisedURL string, target discovery, info fetchInfo) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stat, _ := c.pages.get(normalisedURL)
+	stat.redirectsTo = target.task.NormalisedURL
+	c.pages.put(normalisedURL, stat)
+
+	if !target.internal || target.disallowed || target.excluded {
+		return false
+	}
+
+	targetStat, exists := c.pages.get(target.task.NormalisedURL)
+	if exists && targetStat.fetched {
+		return false
+	}
+
+	if exists {
+		targetStat.depth = min(targetStat.depth, target.task.Depth)
+	} else {
+		targetStat.seed = target.task.Seed
+		targetStat.rawURL = target.task.RawURL
+		targetStat.internal = target.internal
+		targetStat.subdomain = target.subdomain
+		targetStat.scopeHost = target.scopeHost
+		targetStat.depth = target.task.Depth
+	}
+
+	targetStat.scheduled = true
+	targetStat.fetched = true
+	targetStat.attempts = info.attempts
+	targetStat.status = info.statusCode
+	targetStat.contentType = info.contentType
+	targetStat.size = info.size
+	targetStat.latency = info.latency
+	targetStat.redirectedFrom = normalisedURL
+
+	c.pages.put(target.task.NormalisedURL, targetStat)
+
+	return true
+}
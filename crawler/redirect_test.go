@@ -0,0 +1,300 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"codeflow.dananglin.me.uk/apollo/web-crawler/crawler/crawlertest"
+)
+
+func TestRedirects(t *testing.T) {
+	t.Parallel()
+
+	var destinationRequests atomic.Int32
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(
+			w,
+			`<html><body>`+
+				`<a href="/old">Old</a><a href="/moved">Moved</a><a href="/new">New</a>`+
+				`<a href="/loop-a">Loop</a><a href="/long">Long</a>`+
+				`</body></html>`,
+		)
+	})
+	mux.Handle("/old", http.RedirectHandler("/middle", http.StatusMovedPermanently))
+	mux.Handle("/middle", http.RedirectHandler("/new", http.StatusFound))
+	mux.Handle("/moved", http.RedirectHandler("/new", http.StatusMovedPermanently))
+	mux.HandleFunc("/new", func(w http.ResponseWriter, _ *http.Request) {
+		destinationRequests.Add(1)
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><body><a href="/linked-from-new">Next</a></body></html>`)
+	})
+	mux.HandleFunc("/linked-from-new", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><body></body></html>`)
+	})
+	mux.Handle("/loop-a", http.RedirectHandler("/loop-b", http.StatusFound))
+	mux.Handle("/loop-b", http.RedirectHandler("/loop-a", http.StatusFound))
+	mux.Handle("/long", http.RedirectHandler("/long-1", http.StatusFound))
+	mux.Handle("/long-1", http.RedirectHandler("/long-2", http.StatusFound))
+	mux.Handle("/long-2", http.RedirectHandler("/long-3", http.StatusFound))
+	mux.Handle("/long-3", http.RedirectHandler("/new", http.StatusFound))
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	testCrawler, err := New(
+		[]string{server.URL},
+		WithMaxWorkers(1),
+		WithMaxPages(20),
+		WithMaxRedirects(3),
+		WithIgnoreRobots(true),
+		WithRetries(1, 0, 0),
+	)
+	if err != nil {
+		t.Fatalf("Test 'TestRedirects' FAILED: unexpected error creating the crawler: %v", err)
+	}
+
+	testCrawler.crawl(context.Background())
+
+	host := testCrawler.seeds[0].Hostname()
+
+	// The destination reached through /old shares the request of /old so only
+	// the six requested pages are counted: /, /old, /moved, /loop-a, /long
+	// and /linked-from-new.
+	if got, summary := testCrawler.fetchedPages, testCrawler.result().Summary; got != 6 || summary.PagesFetched != 6 {
+		t.Errorf(
+			"Test 'TestRedirects' FAILED: unexpected number of fetched pages: want 6, got %d (%d in the summary)",
+			got,
+			summary.PagesFetched,
+		)
+	}
+
+	// The destination is reached through /old and /moved but it is not
+	// fetched again from the link to it on the base URL's page.
+	if got := destinationRequests.Load(); got != 2 {
+		t.Errorf("Test 'TestRedirects' FAILED: unexpected number of requests to the destination: want 2, got %d", got)
+	}
+
+	old := testCrawler.page(host + "/old")
+	wantHops := []RedirectHop{
+		{URL: server.URL + "/old", Status: http.StatusMovedPermanently},
+		{URL: server.URL + "/middle", Status: http.StatusFound},
+	}
+
+	if !reflect.DeepEqual(old.redirects, wantHops) || old.redirectsTo != host+"/new" || old.status != http.StatusMovedPermanently {
+		t.Errorf(
+			"Test 'TestRedirects' FAILED: unexpected redirect chain for /old: want %v to %s, got %v to %s (status %d)",
+			wantHops,
+			host+"/new",
+			old.redirects,
+			old.redirectsTo,
+			old.status,
+		)
+	}
+
+	if got := testCrawler.page(host + "/linked-from-new"); got.count != 1 || got.referrers[0].Source != server.URL+"/new" {
+		t.Errorf(
+			"Test 'TestRedirects' FAILED: the links on the destination page were not recorded once: got %+v",
+			got,
+		)
+	}
+
+	if loop := testCrawler.page(host + "/loop-a"); !loop.redirectLoop || !strings.Contains(loop.fetchError, errRedirectLoop.Error()) {
+		t.Errorf("Test 'TestRedirects' FAILED: the redirect loop was not detected: got %+v", loop)
+	}
+
+	if long := testCrawler.page(host + "/long"); !strings.Contains(long.fetchError, errTooManyRedirects.Error()) {
+		t.Errorf("Test 'TestRedirects' FAILED: the redirect chain longer than the maximum was followed: got %+v", long)
+	}
+
+	var output strings.Builder
+
+	if err := testCrawler.WriteReport(&output, "", "text", false); err != nil {
+		t.Fatalf("Test 'TestRedirects' FAILED: unexpected error writing the report: %v", err)
+	}
+
+	textReport := output.String()
+
+	wantChain := server.URL + "/old (301) \u2192 " + server.URL + "/middle (302) \u2192 " + host + "/new"
+
+	for _, want := range []string{"REDIRECT CHAINS", wantChain, server.URL + "/loop-b (302) \u2192 LOOP"} {
+		if !strings.Contains(textReport, want) {
+			t.Errorf("Test 'TestRedirects' FAILED: the report does not contain %q:\n%s", want, textReport)
+		}
+	}
+}
+
+func TestRedirectHopsAreChecked(t *testing.T) {
+	t.Parallel()
+
+	fetcher := crawlertest.NewFetcher()
+	fetcher.Add("https://example.com/robots.txt", crawlertest.Response{
+		Status: http.StatusOK,
+		Header: http.Header{"Content-Type": []string{"text/plain"}},
+		Body:   "User-agent: *\nDisallow: /private\n",
+		Delay:  0,
+		Err:    nil,
+	})
+	fetcher.AddHTML("https://example.com", `<html><body>
+<a href="/to-private">Private</a>
+<a href="/to-external">External</a>
+<a href="/to-excluded">Excluded</a>
+<a href="/to-middle">Middle</a>
+</body></html>`)
+	fetcher.AddRedirect("https://example.com/to-private", http.StatusFound, "/private")
+	fetcher.AddRedirect("https://example.com/to-external", http.StatusMovedPermanently, "https://example.org/")
+	fetcher.AddRedirect("https://example.com/to-excluded", http.StatusFound, "/drafts/page")
+	fetcher.AddRedirect("https://example.com/to-middle", http.StatusFound, "/middle")
+	fetcher.AddRedirect("https://example.com/middle", http.StatusFound, "/private/page")
+
+	testCrawler, err := New(
+		[]string{"https://example.com"},
+		WithMaxWorkers(1),
+		WithRetries(1, 0, 0),
+		WithExclude("/drafts/**"),
+		WithFetcher(fetcher),
+	)
+	if err != nil {
+		t.Fatalf("Test 'TestRedirectHopsAreChecked' FAILED: unexpected error creating the crawler: %v", err)
+	}
+
+	result, err := testCrawler.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Test 'TestRedirectHopsAreChecked' FAILED: unexpected error running the crawl: %v", err)
+	}
+
+	disallowedTargets := []string{
+		"https://example.com/private",
+		"https://example.org/",
+		"https://example.com/drafts/page",
+		"https://example.com/private/page",
+	}
+
+	for _, target := range slices.All(disallowedTargets) {
+		if slices.Contains(fetcher.Requests(), http.MethodGet+" "+target) {
+			t.Errorf("Test 'TestRedirectHopsAreChecked' FAILED: the redirect target %s was requested", target)
+		}
+	}
+
+	records := make(map[string]Record)
+
+	for _, record := range slices.All(result.Records) {
+		records[record.Link] = record
+	}
+
+	cases := []struct {
+		link       string
+		wantTarget string
+		wantReason string
+		wantHops   int
+	}{
+		{link: "example.com/to-private", wantTarget: "example.com/private", wantReason: "disallowed by robots.txt", wantHops: 1},
+		{link: "example.com/to-external", wantTarget: "example.org", wantReason: "out of scope", wantHops: 1},
+		{link: "example.com/to-excluded", wantTarget: "example.com/drafts/page", wantReason: "excluded by the URL filters", wantHops: 1},
+		{link: "example.com/to-middle", wantTarget: "example.com/private/page", wantReason: "disallowed by robots.txt", wantHops: 2},
+	}
+
+	for _, tc := range slices.All(cases) {
+		got := records[tc.link]
+
+		if got.RedirectsTo != tc.wantTarget || got.RedirectStopped != tc.wantReason || len(got.Redirects) != tc.wantHops {
+			t.Errorf(
+				"Test 'TestRedirectHopsAreChecked' FAILED: unexpected redirect of %s: want %d hops to %s (%s), got %d hops to %s (%s)",
+				tc.link,
+				tc.wantHops,
+				tc.wantTarget,
+				tc.wantReason,
+				len(got.Redirects),
+				got.RedirectsTo,
+				got.RedirectStopped,
+			)
+		}
+
+		if got.Error != "" || got.Status != http.StatusFound && got.Status != http.StatusMovedPermanently {
+			t.Errorf("Test 'TestRedirectHopsAreChecked' FAILED: the stopped redirect of %s was recorded as an error: %+v", tc.link, got)
+		}
+	}
+
+	if result.Summary.Errors != 0 {
+		t.Errorf("Test 'TestRedirectHopsAreChecked' FAILED: unexpected number of errors: want 0, got %d", result.Summary.Errors)
+	}
+}
+
+func TestRedirectHopsAreRateLimited(t *testing.T) {
+	t.Parallel()
+
+	const interval = 50 * time.Millisecond
+
+	var (
+		mu    sync.Mutex
+		times []time.Time
+	)
+
+	record := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			times = append(times, time.Now())
+			mu.Unlock()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/{$}", http.RedirectHandler("/middle", http.StatusFound))
+	mux.Handle("/middle", http.RedirectHandler("/new", http.StatusFound))
+	mux.HandleFunc("/new", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><body></body></html>`)
+	})
+
+	server := httptest.NewServer(record(mux))
+	defer server.Close()
+
+	testCrawler, err := New(
+		[]string{server.URL},
+		WithMaxWorkers(1),
+		WithIgnoreRobots(true),
+		WithRetries(1, 0, 0),
+		WithRateLimit(float64(time.Second/interval), 0),
+	)
+	if err != nil {
+		t.Fatalf("Test 'TestRedirectHopsAreRateLimited' FAILED: unexpected error creating the crawler: %v", err)
+	}
+
+	if _, err := testCrawler.Run(context.Background()); err != nil {
+		t.Fatalf("Test 'TestRedirectHopsAreRateLimited' FAILED: unexpected error running the crawl: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(times) != 3 {
+		t.Fatalf("Test 'TestRedirectHopsAreRateLimited' FAILED: unexpected number of requests: want 3, got %d", len(times))
+	}
+
+	// Allow for the imprecision of the timers.
+	for ind := 1; ind < len(times); ind++ {
+		if gap := times[ind].Sub(times[ind-1]); gap < interval-5*time.Millisecond {
+			t.Errorf(
+				"Test 'TestRedirectHopsAreRateLimited' FAILED: unexpected gap between the requests %d and %d: want at least %s, got %s",
+				ind,
+				ind+1,
+				interval,
+				gap,
+			)
+		}
+	}
+}
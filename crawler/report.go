@@ -0,0 +1,532 @@
+package crawler
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var errReportFormat = errors.New("unknown report format")
+
+// jsonIndent is the indentation of the JSON report.
+const jsonIndent = "    "
+
+var titlebar = strings.Repeat("\u2500", 80)
+
+// WriteReport writes the report of the crawl's result to the writer. The formats
+// are "text", "json" and "csv". The text and CSV reports list the pages that
+// link to each page if showReferrers is true; the JSON report always does.
+func WriteReport(writer io.Writer, result Result, format string, showReferrers bool) error {
+	report := report{
+		seeds:         result.Seeds,
+		partial:       result.Partial,
+		summary:       result.Summary,
+		records:       slices.Values(result.Records),
+		format:        format,
+		showReferrers: showReferrers,
+	}
+
+	return report.write(writer)
+}
+
+// WriteReport writes the report of the crawl to the writer in one of the formats
+// supported by the WriteReport function. The records are read from the page store
+// one at a time and written straight to the writer so that the report of a crawl
+// kept in a store file is never held in memory. If the seed URL is not empty then
+// the report only contains the pages first found from it. The report must be
+// written after Run has returned and before the crawler is closed.
+func (c *Crawler) WriteReport(writer io.Writer, seed, format string, showReferrers bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	index := newRecordIndex(c.pages, c.summary())
+
+	report := report{
+		seeds:         c.seedURLs(),
+		partial:       c.partial,
+		summary:       index.summary,
+		records:       index.records(seed),
+		format:        format,
+		showReferrers: showReferrers,
+	}
+
+	if seed != "" {
+		report.seeds = []string{seed}
+		report.summary = index.seedSummary(seed)
+	}
+
+	if err := report.write(writer); err != nil {
+		return err
+	}
+
+	if err := c.pages.err(); err != nil {
+		return fmt.Errorf("error reading the pages: %w", err)
+	}
+
+	return nil
+}
+
+// report is the result of a crawl rendered in one of the report formats. The
+// records are iterated over once for every section of the report so they are
+// never held in memory all at once.
+type report struct {
+	seeds         []string
+	partial       bool
+	summary       Summary
+	records       iter.Seq[Record]
+	format        string
+	showReferrers bool
+}
+
+// write writes the report to the writer. The writes are buffered and the buffer
+// keeps the first error, which is returned when the buffer is flushed.
+func (r report) write(writer io.Writer) error {
+	buffered := bufio.NewWriter(writer)
+
+	switch r.format {
+	case "json":
+		if err := r.writeJSON(buffered); err != nil {
+			return err
+		}
+	case "csv":
+		if err := r.writeCSV(buffered); err != nil {
+			return err
+		}
+	case "text":
+		r.writeText(buffered)
+	default:
+		return fmt.Errorf("%w: %q", errReportFormat, r.format)
+	}
+
+	if err := buffered.Flush(); err != nil {
+		return fmt.Errorf("error writing the report: %w", err)
+	}
+
+	return nil
+}
+
+// writeJSON writes the report as an indented JSON document laid out in the same
+// way as an encoded Result. The records are encoded one at a time.
+func (r report) writeJSON(writer *bufio.Writer) error {
+	header := struct {
+		Seeds   []string `json:"seeds"`
+		Partial bool     `json:"partial"`
+		Summary Summary  `json:"summary"`
+	}{
+		Seeds:   r.seeds,
+		Partial: r.partial,
+		Summary: r.summary,
+	}
+
+	data, err := json.MarshalIndent(header, "", jsonIndent)
+	if err != nil {
+		return fmt.Errorf("error marshalling the report to JSON: %w", err)
+	}
+
+	// Reopen the object to add the records.
+	writer.Write(bytes.TrimSuffix(data, []byte("\n}")))
+	writer.WriteString(",\n" + jsonIndent + `"records": [`)
+
+	empty := true
+
+	for record := range r.records {
+		data, err := json.MarshalIndent(record, jsonIndent+jsonIndent, jsonIndent)
+		if err != nil {
+			return fmt.Errorf("error marshalling the record of %s to JSON: %w", record.Link, err)
+		}
+
+		if !empty {
+			writer.WriteString(",")
+		}
+
+		empty = false
+
+		writer.WriteString("\n" + jsonIndent + jsonIndent)
+		writer.Write(data)
+	}
+
+	if !empty {
+		writer.WriteString("\n" + jsonIndent)
+	}
+
+	writer.WriteString("]\n}\n")
+
+	return nil
+}
+
+func (r report) writeText(writer *bufio.Writer) {
+	writer.WriteString("\n" + titlebar)
+	writer.WriteString("\n" + "REPORT for " + strings.Join(r.seeds, ", "))
+	writer.WriteString("\n" + titlebar)
+	writer.WriteString("\nCrawl ended: " + r.summary.StopReason.description() + ".")
+	writer.WriteString(
+		"\nInternal pages fetched: " + withLimit(r.summary.PagesFetched, r.summary.MaxPages) +
+			", external links recorded: " + withLimit(r.summary.ExternalLinks, r.summary.MaxExternalLinks),
+	)
+	writer.WriteString(
+		"\nFetch errors: " + withLimit(r.summary.Errors, r.summary.MaxErrors) +
+			", bytes downloaded: " + withLimit(int(r.summary.BytesFetched), int(r.summary.MaxBytes)) +
+			", duration: " + durationWithLimit(r.summary.DurationMs, r.summary.MaxDurationMs),
+	)
+	writer.WriteString("\n" + titlebar)
+
+	if r.partial {
+		ended := "interrupted"
+
+		switch r.summary.StopReason {
+		case StopReasonMaxDuration, StopReasonMaxErrors, StopReasonMaxBytes:
+			ended = "stopped"
+		}
+
+		writer.WriteString("\nPARTIAL REPORT: the crawl was " + ended + " before it could finish.")
+		writer.WriteString("\n" + titlebar)
+	}
+
+	// The variant URLs are listed under their canonical URL.
+	variants := make(map[string]bool)
+
+	for record := range r.records {
+		for _, variant := range slices.All(record.Variants) {
+			variants[variant] = true
+		}
+	}
+
+	for record := range r.records {
+		if variants[record.Link] {
+			continue
+		}
+
+		links := "links"
+		if record.Count == 1 {
+			links = "link"
+		}
+
+		details := "depth " + strconv.Itoa(record.Depth)
+
+		if record.Status > 0 {
+			details += ", status " + strconv.Itoa(record.Status)
+		}
+
+		if record.Attempts > 0 {
+			details += ", " + strconv.FormatInt(record.LatencyMs, 10) + "ms, " +
+				strconv.FormatInt(record.Size, 10) + " bytes"
+
+			if record.ContentType != "" {
+				details += ", " + record.ContentType
+			}
+		}
+
+		if record.Attempts > 1 {
+			details += ", " + strconv.Itoa(record.Attempts) + " attempts"
+		}
+
+		if record.RedirectsTo != "" {
+			details += ", redirects to " + record.RedirectsTo
+		}
+
+		if record.RedirectStopped != "" {
+			details += " (not followed: " + record.RedirectStopped + ")"
+		}
+
+		if record.Canonical != "" {
+			details += ", canonical " + record.Canonical
+		}
+
+		if directives := record.directives(); directives != "" {
+			details += ", " + directives
+		}
+
+		writer.WriteString(
+			"\nFound " + strconv.Itoa(record.Count) + " " + record.LinkType + " " + links +
+				" to " + record.Link + " (" + details + ")",
+		)
+
+		for _, variant := range slices.All(record.Variants) {
+			writer.WriteString("\n    variant " + variant)
+		}
+
+		if r.showReferrers {
+			for _, ref := range slices.All(record.Referrers) {
+				writer.WriteString("\n    from " + ref.Source)
+
+				if ref.AnchorText != "" {
+					writer.WriteString(" " + strconv.Quote(ref.AnchorText))
+				}
+			}
+		}
+	}
+
+	r.writeSection(writer, "LINKS DISALLOWED by robots.txt", func(record Record) string {
+		if !record.Disallowed {
+			return ""
+		}
+
+		return record.Link
+	})
+
+	r.writeSection(writer, "LINKS EXCLUDED by the include and exclude patterns", func(record Record) string {
+		if !record.Excluded {
+			return ""
+		}
+
+		return record.Link
+	})
+
+	r.writeSection(writer, "PAGES that could not be fetched", func(record Record) string {
+		if record.Attempts == 0 || record.Error == "" {
+			return ""
+		}
+
+		return record.Link + ": " + record.Error
+	})
+
+	r.writeSection(writer, "REDIRECT CHAINS longer than one hop, REDIRECT LOOPS and STOPPED REDIRECTS", func(record Record) string {
+		if len(record.Redirects) <= 1 && !record.RedirectLoop && record.RedirectStopped == "" {
+			return ""
+		}
+
+		return record.redirectChain()
+	})
+
+	r.writeSection(writer, "PAGES marked noindex or nofollow or with nofollow links", func(record Record) string {
+		directives := record.directives()
+		if directives == "" {
+			return ""
+		}
+
+		return record.Link + " (" + directives + ")"
+	})
+
+	r.writeSection(writer, "CANONICAL URLS that point to a different host or to a non-200 URL", func(record Record) string {
+		if record.CanonicalIssue == "" {
+			return ""
+		}
+
+		return record.Link + " \u2192 " + record.Canonical + " (" + record.CanonicalIssue + ")"
+	})
+
+	r.writeSection(writer, "BROKEN EXTERNAL LINKS and the pages that link to them", func(record Record) string {
+		if !record.Broken {
+			return ""
+		}
+
+		problem := "status " + strconv.Itoa(record.Status)
+		if record.Error != "" {
+			problem = record.Error + " error"
+		}
+
+		line := record.Link + " (" + problem + ")"
+
+		for _, ref := range slices.All(record.Referrers) {
+			line += "\n    from " + ref.Source
+		}
+
+		return line
+	})
+
+	r.writeSection(writer, "ORPHAN PAGES found only in the sitemap", func(record Record) string {
+		if !record.Orphan {
+			return ""
+		}
+
+		return record.Link
+	})
+
+	writer.WriteString("\n")
+}
+
+// writeSection writes a section of the text report with a line for every record
+// that belongs to it. The line of a record that does not belong to the section is
+// empty. Nothing is written if no record belongs to the section.
+func (r report) writeSection(writer *bufio.Writer, title string, line func(Record) string) {
+	empty := true
+
+	for record := range r.records {
+		text := line(record)
+		if text == "" {
+			continue
+		}
+
+		if empty {
+			writer.WriteString("\n\n" + titlebar)
+			writer.WriteString("\n" + title)
+			writer.WriteString("\n" + titlebar)
+		}
+
+		empty = false
+
+		writer.WriteString("\n" + text)
+	}
+}
+
+// redirectChain returns every hop of the record's redirect chain followed by the
+// final destination of the chain or a mark if the chain looped. The reason is
+// added after the destination if the chain was stopped before it.
+func (r Record) redirectChain() string {
+	if len(r.Redirects) == 0 {
+		return ""
+	}
+
+	hops := make([]string, 0, len(r.Redirects)+1)
+
+	for _, hop := range slices.All(r.Redirects) {
+		hops = append(hops, hop.URL+" ("+strconv.Itoa(hop.Status)+")")
+	}
+
+	switch {
+	case r.RedirectLoop:
+		hops = append(hops, "LOOP")
+	case r.RedirectStopped != "":
+		hops = append(hops, r.RedirectsTo+" (not followed: "+r.RedirectStopped+")")
+	case r.RedirectsTo != "":
+		hops = append(hops, r.RedirectsTo)
+	}
+
+	return strings.Join(hops, " \u2192 ")
+}
+
+// directives returns the robots directives of the record's page and the number
+// of nofollow links on the page as a comma separated list.
+func (r Record) directives() string {
+	directives := make([]string, 0, 3)
+
+	if r.NoIndex {
+		directives = append(directives, "noindex")
+	}
+
+	if r.NoFollow {
+		directives = append(directives, "nofollow")
+	}
+
+	switch {
+	case r.NofollowLinks == 1:
+		directives = append(directives, "1 nofollow link")
+	case r.NofollowLinks > 1:
+		directives = append(directives, strconv.Itoa(r.NofollowLinks)+" nofollow links")
+	}
+
+	return strings.Join(directives, ", ")
+}
+
+// withLimit returns the total followed by its limit, if there is one.
+func withLimit(total, limit int) string {
+	if limit <= 0 {
+		return strconv.Itoa(total)
+	}
+
+	return strconv.Itoa(total) + "/" + strconv.Itoa(limit)
+}
+
+// durationWithLimit formats the duration in milliseconds, rounded to the second,
+// with its limit if there is one.
+func durationWithLimit(durationMs, limitMs int64) string {
+	duration := (time.Duration(durationMs) * time.Millisecond).Round(time.Second).String()
+
+	if limitMs <= 0 {
+		return duration
+	}
+
+	return duration + "/" + (time.Duration(limitMs) * time.Millisecond).String()
+}
+
+// writeCSV writes the report as CSV with a row for every record, or for every
+// referrer of a record if the referrers are shown. The summary of the crawl is
+// written before the header as comment lines starting with "#" so that it can
+// be skipped by CSV readers that support comments.
+func (r report) writeCSV(writer *bufio.Writer) error {
+	summary := []string{
+		"seeds: " + strings.Join(r.seeds, " "),
+		"partial: " + strconv.FormatBool(r.partial),
+		"stop reason: " + string(r.summary.StopReason) + " (" + r.summary.StopReason.description() + ")",
+		"pages fetched: " + withLimit(r.summary.PagesFetched, r.summary.MaxPages),
+		"external links: " + withLimit(r.summary.ExternalLinks, r.summary.MaxExternalLinks),
+		"fetch errors: " + withLimit(r.summary.Errors, r.summary.MaxErrors),
+		"bytes downloaded: " + withLimit(int(r.summary.BytesFetched), int(r.summary.MaxBytes)),
+		"duration: " + durationWithLimit(r.summary.DurationMs, r.summary.MaxDurationMs),
+	}
+
+	for _, line := range slices.All(summary) {
+		writer.WriteString("# " + line + "\n")
+	}
+
+	header := []string{
+		"LINK", "TYPE", "COUNT", "DEPTH", "DISALLOWED", "EXCLUDED", "ATTEMPTS", "IN_SITEMAP", "ORPHAN",
+		"STATUS", "LATENCY_MS", "SIZE", "CONTENT_TYPE", "ERROR", "BROKEN", "REDIRECT_CHAIN", "REDIRECT_LOOP",
+		"CANONICAL", "CANONICAL_ISSUE", "NOINDEX", "NOFOLLOW", "NOFOLLOW_LINKS",
+	}
+
+	if r.showReferrers {
+		header = append(header, "REFERRER", "ANCHOR_TEXT")
+	}
+
+	csvWriter := csv.NewWriter(writer)
+
+	if err := csvWriter.Write(header); err != nil {
+		return fmt.Errorf("error writing the report: %w", err)
+	}
+
+	for record := range r.records {
+		row := []string{
+			record.Link,
+			record.LinkType,
+			strconv.Itoa(record.Count),
+			strconv.Itoa(record.Depth),
+			strconv.FormatBool(record.Disallowed),
+			strconv.FormatBool(record.Excluded),
+			strconv.Itoa(record.Attempts),
+			strconv.FormatBool(record.InSitemap),
+			strconv.FormatBool(record.Orphan),
+			strconv.Itoa(record.Status),
+			strconv.FormatInt(record.LatencyMs, 10),
+			strconv.FormatInt(record.Size, 10),
+			record.ContentType,
+			record.Error,
+			strconv.FormatBool(record.Broken),
+			record.redirectChain(),
+			strconv.FormatBool(record.RedirectLoop),
+			record.Canonical,
+			record.CanonicalIssue,
+			strconv.FormatBool(record.NoIndex),
+			strconv.FormatBool(record.NoFollow),
+			strconv.Itoa(record.NofollowLinks),
+		}
+
+		if !r.showReferrers {
+			if err := csvWriter.Write(row); err != nil {
+				return fmt.Errorf("error writing the report: %w", err)
+			}
+
+			continue
+		}
+
+		referrers := record.Referrers
+		if len(referrers) == 0 {
+			referrers = []Referrer{{Source: "", AnchorText: ""}}
+		}
+
+		// Write a row for every referrer so that the report can be
+		// filtered by the page that needs to be fixed.
+		for _, ref := range slices.All(referrers) {
+			if err := csvWriter.Write(append(slices.Clip(row), ref.Source, ref.AnchorText)); err != nil {
+				return fmt.Errorf("error writing the report: %w", err)
+			}
+		}
+	}
+
+	csvWriter.Flush()
+
+	if err := csvWriter.Error(); err != nil {
+		return fmt.Errorf("error writing the report: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,240 @@
+package crawler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReport(t *testing.T) {
+	t.Parallel()
+
+	testSeeds := []string{"https://example.org"}
+	testPages := memoryStore{
+		"mastodon.example.social/@benbarlett":                   {count: 4, internal: false, depth: 1, linked: true},
+		"example.org/posts/yet-another-web-crawler-has-emerged": {count: 1, internal: true, depth: 2, linked: true},
+		"example.org/about/contact":                             {count: 10, internal: true, depth: 1, linked: true, inSitemap: true},
+		"example.org/tags/rust":                                 {count: 0, internal: true, depth: 0, inSitemap: true},
+		"github.com/benbarlettdotdev":                           {count: 1, internal: false, depth: 3, linked: true, checked: true, status: 404},
+		"example.org/posts":                                     {count: 4, internal: true, depth: 1, linked: true, attempts: 2},
+		"github.com/dananglin/web-crawler":                      {count: 1, internal: false, depth: 3, linked: true, checked: true, status: 200},
+		"ben-barlett.dev":                                       {count: 1, depth: 1, linked: true, checked: true, errorClass: "dns"},
+		"docs.example.org":                                      {count: 3, internal: true, subdomain: true, depth: 1, linked: true},
+		"example.org":                                           {count: 45, internal: true, depth: 0, linked: true, attempts: 1},
+		"example.org/tags":                                      {count: 4, internal: true, depth: 1, linked: true, excluded: true},
+		"example.org/tags/golang": {
+			count: 2, internal: true, depth: 2, linked: true,
+			referrers: []Referrer{
+				{Source: "https://example.org/tags", AnchorText: "Go"},
+				{Source: "https://example.org/posts/yet-another-web-crawler-has-emerged", AnchorText: "golang"},
+			},
+		},
+	}
+
+	want := Result{
+		Seeds:   []string{"https://example.org"},
+		Partial: false,
+		Summary: Summary{
+			StopReason:       StopReasonMaxExternalLinks,
+			PagesFetched:     2,
+			MaxPages:         10,
+			ExternalLinks:    4,
+			MaxExternalLinks: 4,
+			Errors:           0,
+			MaxErrors:        0,
+			BytesFetched:     0,
+			MaxBytes:         0,
+			DurationMs:       0,
+			MaxDurationMs:    0,
+		},
+		Records: []Record{
+			{
+				Link: "example.org", Count: 45, LinkType: "internal", Depth: 0, Attempts: 1,
+				Referrers: []Referrer{}, Redirects: []RedirectHop{}, Variants: []string{},
+			},
+			{
+				Link: "example.org/about/contact", Count: 10, LinkType: "internal", Depth: 1,
+				InSitemap: true, Referrers: []Referrer{}, Redirects: []RedirectHop{}, Variants: []string{},
+			},
+			{
+				Link: "example.org/posts", Count: 4, LinkType: "internal", Depth: 1, Attempts: 2,
+				Referrers: []Referrer{}, Redirects: []RedirectHop{}, Variants: []string{},
+			},
+			{
+				Link: "example.org/tags", Count: 4, LinkType: "internal", Depth: 1, Excluded: true,
+				Referrers: []Referrer{}, Redirects: []RedirectHop{}, Variants: []string{},
+			},
+			{
+				Link: "mastodon.example.social/@benbarlett", Count: 4, LinkType: "external", Depth: 1,
+				Referrers: []Referrer{}, Redirects: []RedirectHop{}, Variants: []string{},
+			},
+			{
+				Link: "docs.example.org", Count: 3, LinkType: "subdomain", Depth: 1,
+				Referrers: []Referrer{}, Redirects: []RedirectHop{}, Variants: []string{},
+			},
+			{Link: "example.org/tags/golang", Count: 2, LinkType: "internal", Depth: 2, Referrers: []Referrer{
+				{Source: "https://example.org/posts/yet-another-web-crawler-has-emerged", AnchorText: "golang"},
+				{Source: "https://example.org/tags", AnchorText: "Go"},
+			}, Redirects: []RedirectHop{}, Variants: []string{}},
+			{
+				Link: "ben-barlett.dev", Count: 1, LinkType: "external", Depth: 1,
+				Checked: true, Error: "dns", Broken: true, Referrers: []Referrer{}, Redirects: []RedirectHop{}, Variants: []string{},
+			},
+			{
+				Link: "example.org/posts/yet-another-web-crawler-has-emerged", Count: 1, LinkType: "internal", Depth: 2,
+				Referrers: []Referrer{}, Redirects: []RedirectHop{}, Variants: []string{},
+			},
+			{
+				Link: "github.com/benbarlettdotdev", Count: 1, LinkType: "external", Depth: 3,
+				Checked: true, Status: 404, Broken: true, Referrers: []Referrer{}, Redirects: []RedirectHop{}, Variants: []string{},
+			},
+			{
+				Link: "github.com/dananglin/web-crawler", Count: 1, LinkType: "external", Depth: 3,
+				Checked: true, Status: 200, Referrers: []Referrer{}, Redirects: []RedirectHop{}, Variants: []string{},
+			},
+			{
+				Link: "example.org/tags/rust", Count: 0, LinkType: "internal", Depth: 0,
+				InSitemap: true, Orphan: true, Referrers: []Referrer{}, Redirects: []RedirectHop{}, Variants: []string{},
+			},
+		},
+	}
+
+	testSummary := Summary{
+		StopReason:       StopReasonMaxExternalLinks,
+		PagesFetched:     0,
+		MaxPages:         10,
+		ExternalLinks:    0,
+		MaxExternalLinks: 4,
+		Errors:           0,
+		MaxErrors:        0,
+		BytesFetched:     0,
+		MaxBytes:         0,
+		DurationMs:       0,
+		MaxDurationMs:    0,
+	}
+
+	got := newResult(testSeeds, false, testSummary, testPages)
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Test 'TestReport' FAILED: unexpected result created, want: %v\n\nbut got: %v", want, got)
+	} else {
+		t.Logf("Test 'TestReport' PASSED: expected result created, got: %v", got)
+	}
+}
+
+func TestReportCSVWithReferrers(t *testing.T) {
+	t.Parallel()
+
+	testPages := memoryStore{
+		"example.org": {
+			count: 1, internal: true, depth: 0, linked: true, attempts: 1,
+			status: 500, latency: 15 * time.Millisecond, contentType: "text/plain", fetchError: "received a bad status",
+		},
+		"example.org/posts": {
+			count: 2, internal: true, depth: 1, linked: true, attempts: 1,
+			status: 200, latency: 120 * time.Millisecond, size: 2048, contentType: "text/html; charset=utf-8",
+			referrers: []Referrer{
+				{Source: "https://example.org/tags", AnchorText: "Posts, news and updates"},
+				{Source: "https://example.org", AnchorText: "Posts"},
+			},
+		},
+	}
+
+	testSummary := Summary{
+		StopReason:       StopReasonMaxErrors,
+		PagesFetched:     0,
+		MaxPages:         10,
+		ExternalLinks:    0,
+		MaxExternalLinks: 0,
+		Errors:           0,
+		MaxErrors:        1,
+		BytesFetched:     0,
+		MaxBytes:         0,
+		DurationMs:       1600,
+		MaxDurationMs:    60000,
+	}
+
+	//nolint:lll // The rows of the CSV report can't be wrapped.
+	want := `# seeds: https://example.org
+# partial: true
+# stop reason: max-errors (the maximum number of fetch errors was reached)
+# pages fetched: 2/10
+# external links: 0
+# fetch errors: 1/1
+# bytes downloaded: 2048
+# duration: 2s/1m0s
+LINK,TYPE,COUNT,DEPTH,DISALLOWED,EXCLUDED,ATTEMPTS,IN_SITEMAP,ORPHAN,STATUS,LATENCY_MS,SIZE,CONTENT_TYPE,ERROR,BROKEN,REDIRECT_CHAIN,REDIRECT_LOOP,CANONICAL,CANONICAL_ISSUE,NOINDEX,NOFOLLOW,NOFOLLOW_LINKS,REFERRER,ANCHOR_TEXT
+example.org/posts,internal,2,1,false,false,1,false,false,200,120,2048,text/html; charset=utf-8,,false,,false,,,false,false,0,https://example.org,Posts
+example.org/posts,internal,2,1,false,false,1,false,false,200,120,2048,text/html; charset=utf-8,,false,,false,,,false,false,0,https://example.org/tags,"Posts, news and updates"
+example.org,internal,1,0,false,false,1,false,false,500,15,0,text/plain,received a bad status,false,,false,,,false,false,0,,`
+
+	var output strings.Builder
+
+	result := newResult([]string{"https://example.org"}, true, testSummary, testPages)
+
+	if err := WriteReport(&output, result, "csv", true); err != nil {
+		t.Fatalf("Test 'TestReportCSVWithReferrers' FAILED: unexpected error writing the report: %v", err)
+	}
+
+	got := strings.TrimSuffix(output.String(), "\n")
+
+	if got != want {
+		t.Errorf("Test 'TestReportCSVWithReferrers' FAILED: unexpected CSV report, want:\n%s\n\nbut got:\n%s", want, got)
+	} else {
+		t.Logf("Test 'TestReportCSVWithReferrers' PASSED: expected CSV report, got:\n%s", got)
+	}
+
+	// The summary is skipped by a CSV reader that supports comments.
+	reader := csv.NewReader(strings.NewReader(got))
+	reader.Comment = '#'
+
+	rows, err := reader.ReadAll()
+	if err != nil || len(rows) != 4 || rows[0][0] != "LINK" {
+		t.Errorf("Test 'TestReportCSVWithReferrers' FAILED: unable to read the CSV report without its summary: got %d rows (%v)", len(rows), err)
+	}
+}
+
+func TestReportJSONLayout(t *testing.T) {
+	t.Parallel()
+
+	testPages := memoryStore{
+		"example.org": {count: 1, internal: true, depth: 0, linked: true, attempts: 1, status: 200},
+		"example.org/posts": {
+			count:     2,
+			internal:  true,
+			depth:     1,
+			linked:    true,
+			referrers: []Referrer{{Source: "https://example.org", AnchorText: "<Posts>"}},
+		},
+	}
+
+	results := map[string]Result{
+		"with records":    newResult([]string{"https://example.org"}, true, Summary{}, testPages),
+		"without records": newResult([]string{"https://example.org"}, false, Summary{}, newMemoryStore()),
+	}
+
+	for name, result := range results {
+		// The records are written one at a time in the layout of the encoded result.
+		var want strings.Builder
+
+		encoder := json.NewEncoder(&want)
+		encoder.SetIndent("", "    ")
+
+		if err := encoder.Encode(result); err != nil {
+			t.Fatalf("Test 'TestReportJSONLayout' FAILED: unexpected error encoding the result %s: %v", name, err)
+		}
+
+		var got strings.Builder
+
+		if err := WriteReport(&got, result, "json", false); err != nil {
+			t.Fatalf("Test 'TestReportJSONLayout' FAILED: unexpected error writing the report %s: %v", name, err)
+		}
+
+		if got.String() != want.String() {
+			t.Errorf("Test 'TestReportJSONLayout' FAILED: unexpected JSON report %s, want:\n%s\n\nbut got:\n%s", name, want.String(), got.String())
+		}
+	}
+}
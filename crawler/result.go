@@ -0,0 +1,358 @@
+package crawler
+
+import (
+	"cmp"
+	"iter"
+	"slices"
+	"strconv"
+	"strings"
+
+	"codeflow.dananglin.me.uk/apollo/web-crawler/internal/scope"
+)
+
+// Result is the result of a crawl. There is a record of every page that was found.
+// Partial is true if the crawl was interrupted before it could finish.
+type Result struct {
+	Seeds   []string `json:"seeds"`
+	Partial bool     `json:"partial"`
+	Summary Summary  `json:"summary"`
+	Records []Record `json:"records"`
+}
+
+// Summary describes how the crawl ended and the totals counted against its limits
+// and stop conditions. A limit of 0 means there is no limit. Errors is the number
+// of pages that could not be fetched and BytesFetched is the total size of the
+// fetched pages.
+type Summary struct {
+	StopReason       StopReason `json:"stopReason"`
+	PagesFetched     int        `json:"pagesFetched"`
+	MaxPages         int        `json:"maxPages"`
+	ExternalLinks    int        `json:"externalLinks"`
+	MaxExternalLinks int        `json:"maxExternalLinks"`
+	Errors           int        `json:"errors"`
+	MaxErrors        int        `json:"maxErrors"`
+	BytesFetched     int64      `json:"bytesFetched"`
+	MaxBytes         int64      `json:"maxBytes"`
+	DurationMs       int64      `json:"durationMs"`
+	MaxDurationMs    int64      `json:"maxDurationMs"`
+}
+
+// Record is the record of a page found during the crawl. Count is the number of
+// links to the page and LinkType is the type of the page within the crawl's scope.
+// Seed is the seed URL from which the page was first found. RedirectedFrom is set
+// when the page was fetched as the destination of another page's redirect and
+// RedirectStopped is the reason why the page's redirect was not followed.
+type Record struct {
+	Link        string     `json:"link"`
+	Count       int        `json:"count"`
+	LinkType    string     `json:"linkType"`
+	Depth       int        `json:"depth"`
+	Seed        string     `json:"seed"`
+	Disallowed  bool       `json:"disallowed"`
+	Excluded    bool       `json:"excluded"`
+	Attempts    int        `json:"attempts"`
+	InSitemap   bool       `json:"inSitemap"`
+	Orphan      bool       `json:"orphan"`
+	Checked     bool       `json:"checked"`
+	Status      int        `json:"status"`
+	LatencyMs   int64      `json:"latencyMs"`
+	Size        int64      `json:"size"`
+	ContentType string     `json:"contentType"`
+	Error       string     `json:"error"`
+	Broken      bool       `json:"broken"`
+	Referrers   []Referrer `json:"referrers"`
+
+	Redirects       []RedirectHop `json:"redirects"`
+	RedirectsTo     string        `json:"redirectsTo"`
+	RedirectLoop    bool          `json:"redirectLoop"`
+	RedirectStopped string        `json:"redirectStopped"`
+	RedirectedFrom  string        `json:"redirectedFrom"`
+
+	Canonical      string   `json:"canonical"`
+	CanonicalIssue string   `json:"canonicalIssue"`
+	Variants       []string `json:"variants"`
+
+	NoIndex       bool `json:"noIndex"`
+	NoFollow      bool `json:"noFollow"`
+	NofollowLinks int  `json:"nofollowLinks"`
+}
+
+// newResult returns the result of the crawl of the seed URLs with a record of
+// every page read from the page store. The totals of the summary are counted
+// from the pages.
+func newResult(seeds []string, partial bool, summary Summary, pages pageStore) Result {
+	index := newRecordIndex(pages, summary)
+
+	return Result{
+		Seeds:   seeds,
+		Partial: partial,
+		Summary: index.summary,
+		Records: slices.AppendSeq(make([]Record, 0, len(index.keys)), index.records("")),
+	}
+}
+
+// recordIndex is the order of the records in the result. Only the link and the
+// number of links to every page are kept in memory; the records are read back
+// from the page store one at a time when they are iterated over. The totals of
+// the summaries are counted when the index is built.
+type recordIndex struct {
+	pages   pageStore
+	keys    []recordKey
+	base    Summary
+	summary Summary
+	bySeed  map[string]Summary
+}
+
+// recordKey is the position of a record in the result.
+type recordKey struct {
+	link  string
+	count int
+}
+
+// newRecordIndex returns the index of the pages in the store. The totals of the
+// pages are added to the summary, which holds the limits of the crawl.
+func newRecordIndex(pages pageStore, summary Summary) recordIndex {
+	index := recordIndex{
+		pages:   pages,
+		keys:    make([]recordKey, 0, pages.len()),
+		base:    summary,
+		summary: summary,
+		bySeed:  make(map[string]Summary),
+	}
+
+	for link, stats := range pages.all() {
+		index.keys = append(index.keys, recordKey{link: link, count: stats.count})
+
+		seedSummary, ok := index.bySeed[stats.seed]
+		if !ok {
+			seedSummary = summary
+		}
+
+		addTotals(&index.summary, stats.totals())
+		addTotals(&seedSummary, stats.totals())
+
+		index.bySeed[stats.seed] = seedSummary
+	}
+
+	// First sort records by count (in reverse order hopefully)
+	// Then sort records by name if two elements have the same count.
+	slices.SortFunc(index.keys, func(a, b recordKey) int {
+		if n := cmp.Compare(a.count, b.count); n != 0 {
+			return -1 * n
+		}
+
+		return strings.Compare(a.link, b.link)
+	})
+
+	return index
+}
+
+// pageTotals is the part of a page that is counted in the totals of a summary.
+type pageTotals struct {
+	external   bool
+	requested  bool
+	redirected bool
+	size       int64
+	failed     bool
+}
+
+// totals returns the part of the page that is counted in the totals of a summary.
+func (s pageStat) totals() pageTotals {
+	return pageTotals{
+		external:   !s.internal,
+		requested:  s.attempts > 0,
+		redirected: s.redirectedFrom != "",
+		size:       s.size,
+		failed:     s.fetchError != "",
+	}
+}
+
+// totals returns the part of the record's page that is counted in the totals of a summary.
+func (r Record) totals() pageTotals {
+	return pageTotals{
+		external:   r.LinkType == string(scope.External),
+		requested:  r.Attempts > 0,
+		redirected: r.RedirectedFrom != "",
+		size:       r.Size,
+		failed:     r.Error != "",
+	}
+}
+
+// addTotals adds the page to the totals of the summary.
+func addTotals(summary *Summary, page pageTotals) {
+	if page.external {
+		summary.ExternalLinks++
+	}
+
+	if !page.requested {
+		return
+	}
+
+	// A page reached through a redirect shares the request of its source page.
+	if !page.redirected {
+		summary.PagesFetched++
+	}
+
+	summary.BytesFetched += page.size
+
+	if page.failed {
+		summary.Errors++
+	}
+}
+
+// seedSummary returns the summary with the totals of the pages first found
+// from the seed URL.
+func (i recordIndex) seedSummary(seed string) Summary {
+	if summary, ok := i.bySeed[seed]; ok {
+		return summary
+	}
+
+	return i.base
+}
+
+// records returns an iterator over the records in the order of the result. Only
+// the records of the pages first found from the seed URL are returned if the seed
+// URL is not empty. The iterator can be used more than once.
+func (i recordIndex) records(seed string) iter.Seq[Record] {
+	return func(yield func(Record) bool) {
+		for _, key := range slices.All(i.keys) {
+			stats, ok := i.pages.get(key.link)
+			if !ok || (seed != "" && stats.seed != seed) {
+				continue
+			}
+
+			if !yield(i.record(key.link, stats)) {
+				return
+			}
+		}
+	}
+}
+
+// record returns the record of the page. The page of the canonical URL is read
+// from the store to flag a canonical URL that was fetched with a status other
+// than 200.
+func (i recordIndex) record(link string, stats pageStat) Record {
+	linkType := scope.Internal
+
+	switch {
+	case !stats.internal:
+		linkType = scope.External
+	case stats.subdomain:
+		linkType = scope.Subdomain
+	case stats.scopeHost:
+		linkType = scope.ScopeHost
+	}
+
+	record := Record{
+		Link:        link,
+		Count:       stats.count,
+		LinkType:    string(linkType),
+		Depth:       stats.depth,
+		Seed:        stats.seed,
+		Disallowed:  stats.disallowed,
+		Excluded:    stats.excluded,
+		Attempts:    stats.attempts,
+		InSitemap:   stats.inSitemap,
+		Orphan:      stats.inSitemap && !stats.linked,
+		Checked:     stats.checked,
+		Status:      stats.status,
+		LatencyMs:   stats.latency.Milliseconds(),
+		Size:        stats.size,
+		ContentType: stats.contentType,
+		Error:       cmp.Or(stats.fetchError, stats.errorClass),
+		Broken:      stats.checked && (stats.errorClass != "" || stats.status >= 400),
+		Referrers:   sortedReferrers(stats.referrers),
+
+		Redirects:       append([]RedirectHop{}, stats.redirects...),
+		RedirectsTo:     stats.redirectsTo,
+		RedirectLoop:    stats.redirectLoop,
+		RedirectStopped: stats.redirectStopped,
+		RedirectedFrom:  stats.redirectedFrom,
+
+		Canonical:      stats.canonical,
+		CanonicalIssue: "",
+		Variants:       slices.Sorted(slices.Values(stats.variants)),
+
+		NoIndex:       stats.noIndex,
+		NoFollow:      stats.noFollow,
+		NofollowLinks: stats.nofollowLinks,
+	}
+
+	if record.Variants == nil {
+		record.Variants = []string{}
+	}
+
+	issues := make([]string, 0, 2)
+
+	if stats.canonicalOtherHost {
+		issues = append(issues, "points to a different host")
+	}
+
+	if stats.canonical != "" {
+		if canonical, ok := i.pages.get(stats.canonical); ok && canonical.attempts > 0 && canonical.status != 200 {
+			issue := "points to a URL that could not be fetched"
+			if canonical.status > 0 {
+				issue = "points to a URL with status " + strconv.Itoa(canonical.status)
+			}
+
+			issues = append(issues, issue)
+		}
+	}
+
+	record.CanonicalIssue = strings.Join(issues, "; ")
+
+	return record
+}
+
+// BySeed splits the result into a result for every seed URL. Each result only
+// contains the records of the pages first found from its seed URL and the totals
+// of its summary are counted from those pages.
+func (r Result) BySeed() []Result {
+	results := make([]Result, len(r.Seeds))
+
+	for ind, seed := range slices.All(r.Seeds) {
+		summary := r.Summary
+		summary.PagesFetched = 0
+		summary.ExternalLinks = 0
+		summary.Errors = 0
+		summary.BytesFetched = 0
+
+		records := make([]Record, 0)
+
+		for _, record := range slices.All(r.Records) {
+			if record.Seed != seed {
+				continue
+			}
+
+			addTotals(&summary, record.totals())
+
+			records = append(records, record)
+		}
+
+		results[ind] = Result{
+			Seeds:   []string{seed},
+			Partial: r.Partial,
+			Summary: summary,
+			Records: records,
+		}
+	}
+
+	return results
+}
+
+// sortedReferrers returns a sorted copy of the referrers so that the order of the
+// referrers does not depend on the order in which the pages were fetched.
+func sortedReferrers(referrers []Referrer) []Referrer {
+	sorted := make([]Referrer, len(referrers))
+	copy(sorted, referrers)
+
+	slices.SortFunc(sorted, func(a, b Referrer) int {
+		if n := strings.Compare(a.Source, b.Source); n != 0 {
+			return n
+		}
+
+		return strings.Compare(a.AnchorText, b.AnchorText)
+	})
+
+	return sorted
+}
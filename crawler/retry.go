@@ -0,0 +1,184 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// retryPolicy describes how failed requests are retried.
+type retryPolicy struct {
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+}
+
+// backoff returns the time to wait before the next attempt after the given
+// number of failed attempts. The delay doubles after every attempt up to
+// the maximum delay, and half of it is randomised so that workers don't
+// retry in lockstep.
+func (p retryPolicy) backoff(attempt int) time.Duration {
+	if p.baseDelay <= 0 {
+		return 0
+	}
+
+	delay := p.baseDelay
+
+	for range attempt - 1 {
+		delay *= 2
+
+		if p.maxDelay > 0 && delay >= p.maxDelay {
+			delay = p.maxDelay
+
+			break
+		}
+	}
+
+	half := delay / 2
+
+	return half + rand.N(half+1)
+}
+
+// retryDelay evaluates whether the error from the given attempt can be retried
+// and returns how long to wait before doing so. The server's Retry-After value is
+// used when it is provided. Errors are not retried when there are no attempts
+// left or if the server asks the crawler to wait for longer than the maximum delay.
+func (p retryPolicy) retryDelay(err error, attempt int) (time.Duration, bool) {
+	if attempt >= p.maxAttempts {
+		return 0, false
+	}
+
+	retryable, retryAfter := isRetryable(err)
+	if !retryable {
+		return 0, false
+	}
+
+	if retryAfter > 0 {
+		if p.maxDelay > 0 && retryAfter > p.maxDelay {
+			return 0, false
+		}
+
+		return retryAfter, true
+	}
+
+	return p.backoff(attempt), true
+}
+
+// isRetryable evaluates whether the error from a request is transient and can
+// be retried. Timeouts, connection resets, 5xx statuses and the 429 status are
+// considered to be transient. The server's Retry-After value is also returned
+// if it was provided.
+func isRetryable(err error) (bool, time.Duration) {
+	var statusErr *statusError
+	if errors.As(err, &statusErr) {
+		retryable := statusErr.statusCode == http.StatusTooManyRequests ||
+			statusErr.statusCode >= http.StatusInternalServerError
+
+		return retryable, statusErr.retryAfter
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true, 0
+	}
+
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		return true, 0
+	}
+
+	return false, 0
+}
+
+// parseRetryAfter parses the value of the Retry-After header which is either
+// a number of seconds or an HTTP date. Zero is returned if the value is
+// empty or invalid.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0
+	}
+
+	return max(date.Sub(now), 0)
+}
+
+// getHTMLWithRetries retrieves the HTML document from the URL, retrying
+// transient failures according to the crawler's retry policy. Every attempt
+// waits for its turn with the host limiter, which is paused for every worker
+// when the server responds with a Retry-After header. The metadata of the last response,
+// including the number of attempts made, is returned along with the document.
+func (c *Crawler) getHTMLWithRetries(ctx context.Context, host string, crawlDelay time.Duration, rawURL string) (string, fetchInfo, error) {
+	var info fetchInfo
+
+	attempt := 0
+
+	for {
+		attempt++
+
+		if err := c.limiter.wait(ctx, host, crawlDelay); err != nil {
+			info.attempts = attempt - 1
+
+			return "", info, err
+		}
+
+		htmlDoc, attemptInfo, err := c.getHTML(ctx, rawURL)
+
+		info = attemptInfo
+		info.attempts = attempt
+
+		if err == nil {
+			return htmlDoc, info, nil
+		}
+
+		if ctx.Err() != nil {
+			return "", info, err
+		}
+
+		delay, retry := c.retryPolicy.retryDelay(err, attempt)
+		if !retry {
+			return "", info, err
+		}
+
+		// The other workers also wait for the delay that the server asked for
+		// before they send their next request to the host.
+		if _, retryAfter := isRetryable(err); retryAfter > 0 {
+			c.limiter.pause(host, delay)
+		}
+
+		c.logger.Warn(
+			"retrying page",
+			slog.String("url", rawURL),
+			slog.Int("attempt", attempt),
+			slog.Int("maxAttempts", c.retryPolicy.maxAttempts),
+			slog.Int("status", info.statusCode),
+			slog.Duration("duration", info.latency),
+			slog.Duration("delay", delay.Round(time.Millisecond)),
+			slog.Any("error", err),
+		)
+
+		timer := time.NewTimer(delay)
+
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+
+			return "", info, fmt.Errorf("stopped retrying %s: %w", rawURL, ctx.Err())
+		}
+	}
+}
@@ -0,0 +1,281 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, time.August, 27, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name  string
+		value string
+		want  time.Duration
+	}{
+		{
+			name:  "Empty value",
+			value: "",
+			want:  0,
+		},
+		{
+			name:  "Number of seconds",
+			value: "120",
+			want:  2 * time.Minute,
+		},
+		{
+			name:  "HTTP date",
+			value: "Tue, 27 Aug 2024 12:00:30 GMT",
+			want:  30 * time.Second,
+		},
+		{
+			name:  "HTTP date in the past",
+			value: "Tue, 27 Aug 2024 11:00:00 GMT",
+			want:  0,
+		},
+		{
+			name:  "Invalid value",
+			value: "soon",
+			want:  0,
+		},
+	}
+
+	for ind, tc := range slices.All(cases) {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := parseRetryAfter(tc.value, now)
+			if got != tc.want {
+				t.Errorf(
+					"Test %d - '%s' FAILED: unexpected duration: want %s, got %s",
+					ind+1,
+					tc.name,
+					tc.want,
+					got,
+				)
+			} else {
+				t.Logf(
+					"Test %d - '%s' PASSED: expected duration: got %s",
+					ind+1,
+					tc.name,
+					got,
+				)
+			}
+		})
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "Service unavailable",
+			err:  &statusError{url: "https://example.com", statusCode: http.StatusServiceUnavailable},
+			want: true,
+		},
+		{
+			name: "Too many requests",
+			err:  fmt.Errorf("wrapped: %w", &statusError{url: "https://example.com", statusCode: http.StatusTooManyRequests}),
+			want: true,
+		},
+		{
+			name: "Not found",
+			err:  &statusError{url: "https://example.com", statusCode: http.StatusNotFound},
+			want: false,
+		},
+		{
+			name: "Connection reset",
+			err:  fmt.Errorf("error getting the response: %w", syscall.ECONNRESET),
+			want: true,
+		},
+		{
+			name: "Deadline exceeded",
+			err:  fmt.Errorf("error getting the response: %w", context.DeadlineExceeded),
+			want: true,
+		},
+		{
+			name: "Unexpected content type",
+			err:  errors.New("unexpected content type received: want text/html, got application/pdf"),
+			want: false,
+		},
+	}
+
+	for ind, tc := range slices.All(cases) {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, _ := isRetryable(tc.err)
+			if got != tc.want {
+				t.Errorf(
+					"Test %d - '%s' FAILED: unexpected result: want %t, got %t",
+					ind+1,
+					tc.name,
+					tc.want,
+					got,
+				)
+			} else {
+				t.Logf(
+					"Test %d - '%s' PASSED: expected result: got %t",
+					ind+1,
+					tc.name,
+					got,
+				)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	t.Parallel()
+
+	policy := retryPolicy{
+		maxAttempts: 10,
+		baseDelay:   100 * time.Millisecond,
+		maxDelay:    time.Second,
+	}
+
+	cases := []struct {
+		attempt int
+		wantMin time.Duration
+		wantMax time.Duration
+	}{
+		{attempt: 1, wantMin: 50 * time.Millisecond, wantMax: 100 * time.Millisecond},
+		{attempt: 2, wantMin: 100 * time.Millisecond, wantMax: 200 * time.Millisecond},
+		{attempt: 3, wantMin: 200 * time.Millisecond, wantMax: 400 * time.Millisecond},
+		{attempt: 8, wantMin: 500 * time.Millisecond, wantMax: time.Second},
+	}
+
+	for ind, tc := range slices.All(cases) {
+		got := policy.backoff(tc.attempt)
+		if got < tc.wantMin || got > tc.wantMax {
+			t.Errorf(
+				"Test %d - 'Backoff after attempt %d' FAILED: unexpected delay: want between %s and %s, got %s",
+				ind+1,
+				tc.attempt,
+				tc.wantMin,
+				tc.wantMax,
+				got,
+			)
+		} else {
+			t.Logf(
+				"Test %d - 'Backoff after attempt %d' PASSED: expected delay: got %s",
+				ind+1,
+				tc.attempt,
+				got,
+			)
+		}
+	}
+}
+
+func TestGetHTMLWithRetries(t *testing.T) {
+	t.Parallel()
+
+	var requests atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		switch requests.Add(1) {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, "<html><body>Hello</body></html>")
+		}
+	}))
+	defer server.Close()
+
+	testCrawler, err := New(
+		[]string{server.URL},
+		WithMaxWorkers(1),
+		WithIgnoreRobots(true),
+		WithRetries(3, time.Millisecond, 10*time.Millisecond),
+	)
+	if err != nil {
+		t.Fatalf("Test 'TestGetHTMLWithRetries' FAILED: unexpected error creating the crawler: %v", err)
+	}
+
+	_, info, err := testCrawler.getHTMLWithRetries(context.Background(), "", 0, server.URL)
+	if err != nil {
+		t.Fatalf("Test 'TestGetHTMLWithRetries' FAILED: unexpected error: %v", err)
+	}
+
+	attempts := info.attempts
+
+	if attempts != 3 {
+		t.Errorf("Test 'TestGetHTMLWithRetries' FAILED: unexpected number of attempts: want 3, got %d", attempts)
+	} else {
+		t.Logf("Test 'TestGetHTMLWithRetries' PASSED: expected number of attempts: got %d", attempts)
+	}
+
+	if info.statusCode != http.StatusOK || info.contentType != "text/html" || info.size != 31 {
+		t.Errorf(
+			"Test 'TestGetHTMLWithRetries' FAILED: unexpected response metadata: want status 200, text/html and 31 bytes, got %+v",
+			info,
+		)
+	}
+}
+
+func TestRetryAfterPausesTheHost(t *testing.T) {
+	t.Parallel()
+
+	var requests atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if requests.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, "<html><body>Hello</body></html>")
+	}))
+	defer server.Close()
+
+	testCrawler, err := New(
+		[]string{server.URL},
+		WithMaxWorkers(1),
+		WithIgnoreRobots(true),
+		WithRetries(2, time.Millisecond, 10*time.Second),
+	)
+	if err != nil {
+		t.Fatalf("Test 'TestRetryAfterPausesTheHost' FAILED: unexpected error creating the crawler: %v", err)
+	}
+
+	host := testCrawler.seeds[0].Host
+	start := time.Now()
+
+	if _, _, err := testCrawler.getHTMLWithRetries(context.Background(), host, 0, server.URL); err != nil {
+		t.Fatalf("Test 'TestRetryAfterPausesTheHost' FAILED: unexpected error: %v", err)
+	}
+
+	// The host was paused for every worker, not only for the worker that retried.
+	testCrawler.limiter.mu.Lock()
+	pausedUntil := testCrawler.limiter.next[host]
+	testCrawler.limiter.mu.Unlock()
+
+	if pausedUntil.Sub(start) < time.Second {
+		t.Errorf(
+			"Test 'TestRetryAfterPausesTheHost' FAILED: the host was not paused for the Retry-After delay: paused for %s",
+			pausedUntil.Sub(start),
+		)
+	}
+}
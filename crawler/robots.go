@@ -0,0 +1,143 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"codeflow.dananglin.me.uk/apollo/web-crawler/internal/robots"
+)
+
+// maxRobotsSize is the maximum number of bytes of a robots.txt file that
+// is parsed. Anything beyond this is ignored as recommended by RFC 9309.
+const maxRobotsSize = 500 * 1024
+
+// robotsEntry holds the robots.txt rules for a single origin. The rules are
+// fetched once by the first worker to request them. ready is set under the
+// crawler's lock once the rules are stored.
+type robotsEntry struct {
+	once  sync.Once
+	rules robots.Rules
+	ready bool
+}
+
+// robotsRules returns the robots.txt rules that apply to the crawler for the
+// origin of the given URL. The robots.txt file is fetched and parsed the first
+// time an origin is seen. All paths are allowed if the crawler is configured
+// to ignore robots.txt files. All paths are disallowed if the context is cancelled
+// before the rules are retrieved, in which case the rules are not kept so that
+// they are retrieved again the next time they are needed.
+func (c *Crawler) robotsRules(ctx context.Context, parsedURL *url.URL) robots.Rules {
+	if c.ignoreRobots {
+		return robots.AllowAll()
+	}
+
+	origin := parsedURL.Scheme + "://" + parsedURL.Host
+
+	c.mu.Lock()
+
+	entry, exists := c.robots[origin]
+	if !exists {
+		entry = &robotsEntry{}
+		c.robots[origin] = entry
+	}
+
+	c.mu.Unlock()
+
+	entry.once.Do(func() {
+		if err := c.limiter.wait(ctx, parsedURL.Host, 0); err != nil {
+			entry.rules = robots.DisallowAll()
+			c.forgetRobots(origin, entry)
+
+			return
+		}
+
+		rules, err := c.getRobots(ctx, origin)
+		if err != nil && ctx.Err() != nil {
+			entry.rules = robots.DisallowAll()
+			c.forgetRobots(origin, entry)
+
+			return
+		}
+
+		if err != nil {
+			c.logger.Warn(
+				"unable to retrieve the robots.txt file, all pages on this site will be skipped",
+				slog.String("url", origin+"/robots.txt"),
+				slog.Any("error", err),
+			)
+		}
+
+		entry.rules = rules
+
+		c.mu.Lock()
+		entry.ready = true
+		c.mu.Unlock()
+	})
+
+	return entry.rules
+}
+
+// forgetRobots removes the entry of the origin's robots.txt rules so that the
+// rules are retrieved again by the next worker that needs them.
+func (c *Crawler) forgetRobots(origin string, entry *robotsEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.robots[origin] == entry {
+		delete(c.robots, origin)
+	}
+}
+
+// knownCrawlDelay returns the crawl delay of the origin of the URL if its
+// robots.txt rules were already retrieved, without retrieving them otherwise.
+func (c *Crawler) knownCrawlDelay(parsedURL *url.URL) time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, exists := c.robots[parsedURL.Scheme+"://"+parsedURL.Host]
+	if !exists || !entry.ready {
+		return 0
+	}
+
+	return entry.rules.CrawlDelay()
+}
+
+// getRobots retrieves and parses the robots.txt file from the origin.
+// As described in RFC 9309, all paths are allowed if the file is unavailable
+// (a 4xx status) and all paths are disallowed if the file is unreachable
+// (a 5xx status or a network error).
+func (c *Crawler) getRobots(ctx context.Context, origin string) (robots.Rules, error) {
+	rawURL := origin + "/robots.txt"
+
+	resp, _, err := c.do(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return robots.DisallowAll(), err
+	}
+
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 500:
+		return robots.DisallowAll(), fmt.Errorf(
+			"received a bad status from %s: (%d) %s",
+			rawURL,
+			resp.StatusCode,
+			resp.Status,
+		)
+	case resp.StatusCode >= 400:
+		return robots.AllowAll(), nil
+	}
+
+	rules, err := robots.Parse(io.LimitReader(resp.Body, maxRobotsSize), c.userAgent)
+	if err != nil {
+		return robots.DisallowAll(), fmt.Errorf("error parsing %s: %w", rawURL, err)
+	}
+
+	return rules, nil
+}
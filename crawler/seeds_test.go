@@ -0,0 +1,162 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"codeflow.dananglin.me.uk/apollo/web-crawler/crawler/crawlertest"
+)
+
+func TestMultipleSeeds(t *testing.T) {
+	t.Parallel()
+
+	newSite := func(body string) *httptest.Server {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/{$}", func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, body)
+		})
+		mux.HandleFunc("/", func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, `<html><body></body></html>`)
+		})
+
+		return httptest.NewServer(mux)
+	}
+
+	blog := newSite(`<html><body><a href="/posts">Posts</a></body></html>`)
+	defer blog.Close()
+
+	// The shop is reached through localhost so that its host differs from the blog's host.
+	shop := newSite(`<html><body><a href="/basket">Basket</a><a href="` + blog.URL + `/posts">Blog</a></body></html>`)
+	defer shop.Close()
+
+	shopURL := strings.Replace(shop.URL, "127.0.0.1", "localhost", 1)
+
+	testCrawler, err := New(
+		[]string{blog.URL, shopURL, blog.URL},
+		WithMaxWorkers(1),
+		WithIgnoreRobots(true),
+		WithRetries(1, 0, 0),
+	)
+	if err != nil {
+		t.Fatalf("Test 'TestMultipleSeeds' FAILED: unexpected error creating the crawler: %v", err)
+	}
+
+	if got := len(testCrawler.seeds); got != 2 {
+		t.Fatalf("Test 'TestMultipleSeeds' FAILED: unexpected number of seed URLs: want 2, got %d", got)
+	}
+
+	result, err := testCrawler.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Test 'TestMultipleSeeds' FAILED: unexpected error running the crawl: %v", err)
+	}
+
+	results := result.BySeed()
+	if len(results) != 2 {
+		t.Fatalf("Test 'TestMultipleSeeds' FAILED: unexpected number of results: want 2, got %d", len(results))
+	}
+
+	cases := []struct {
+		seed      string
+		wantLinks []string
+	}{
+		{seed: blog.URL, wantLinks: []string{"127.0.0.1", "127.0.0.1/posts"}},
+		{seed: shopURL, wantLinks: []string{"localhost", "localhost/basket"}},
+	}
+
+	// The blog's posts are first found from the blog as the seed URLs are crawled in order.
+	for ind, tc := range slices.All(cases) {
+		got := results[ind]
+
+		if !slices.Equal(got.Seeds, []string{tc.seed}) || got.Summary.PagesFetched != 2 {
+			t.Errorf(
+				"Test 'TestMultipleSeeds' FAILED: unexpected result: want seed %s and 2 pages fetched, got seeds %v and %d pages fetched",
+				tc.seed,
+				got.Seeds,
+				got.Summary.PagesFetched,
+			)
+		}
+
+		links := make([]string, 0, len(got.Records))
+
+		for _, record := range got.Records {
+			if record.Attempts != 1 || record.LinkType != "internal" || record.Seed != tc.seed {
+				t.Errorf("Test 'TestMultipleSeeds' FAILED: unexpected record for %s: %+v", tc.seed, record)
+			}
+
+			links = append(links, record.Link)
+		}
+
+		slices.Sort(links)
+
+		if !slices.Equal(links, tc.wantLinks) {
+			t.Errorf("Test 'TestMultipleSeeds' FAILED: unexpected pages for %s: want %v, got %v", tc.seed, tc.wantLinks, links)
+		} else {
+			t.Logf("Test 'TestMultipleSeeds' PASSED: expected pages for %s: got %v", tc.seed, links)
+		}
+	}
+}
+
+func TestMultipleSeedsWithCanonicalPages(t *testing.T) {
+	t.Parallel()
+
+	fetcher := crawlertest.NewFetcher()
+	fetcher.AddHTML("https://blog.example", `<html><body><a href="/post/amp">Post</a></body></html>`)
+	fetcher.AddHTML(
+		"https://blog.example/post/amp",
+		`<html><head><link rel="canonical" href="https://blog.example/post"></head><body></body></html>`,
+	)
+	fetcher.AddHTML("https://blog.example/post", `<html><body><a href="/post/comments">Comments</a></body></html>`)
+	fetcher.AddHTML("https://blog.example/post/comments", `<html><body></body></html>`)
+	fetcher.AddHTML("https://shop.example", `<html><body></body></html>`)
+
+	testCrawler, err := New(
+		[]string{"https://blog.example", "https://shop.example"},
+		WithMaxWorkers(1),
+		WithIgnoreRobots(true),
+		WithRetries(1, 0, 0),
+		WithFetcher(fetcher),
+	)
+	if err != nil {
+		t.Fatalf("Test 'TestMultipleSeedsWithCanonicalPages' FAILED: unexpected error creating the crawler: %v", err)
+	}
+
+	result, err := testCrawler.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Test 'TestMultipleSeedsWithCanonicalPages' FAILED: unexpected error running the crawl: %v", err)
+	}
+
+	results := result.BySeed()
+	if len(results) != 2 {
+		t.Fatalf("Test 'TestMultipleSeedsWithCanonicalPages' FAILED: unexpected number of results: want 2, got %d", len(results))
+	}
+
+	links := make([]string, 0, len(results[0].Records))
+
+	for _, record := range results[0].Records {
+		links = append(links, record.Link)
+	}
+
+	slices.Sort(links)
+
+	// The canonical page and the pages found on it belong to the seed of the variant.
+	want := []string{"blog.example", "blog.example/post", "blog.example/post/amp", "blog.example/post/comments"}
+
+	if !slices.Equal(links, want) {
+		t.Errorf("Test 'TestMultipleSeedsWithCanonicalPages' FAILED: unexpected pages for the blog: want %v, got %v", want, links)
+	}
+
+	if got := len(results[0].Records) + len(results[1].Records); got != len(result.Records) {
+		t.Errorf(
+			"Test 'TestMultipleSeedsWithCanonicalPages' FAILED: records were dropped from the per-seed results: want %d, got %d",
+			len(result.Records),
+			got,
+		)
+	}
+}
@@ -0,0 +1,203 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+
+	"codeflow.dananglin.me.uk/apollo/web-crawler/internal/sitemap"
+)
+
+// maxSitemapSize is the maximum number of bytes of a sitemap that is parsed.
+// This is the maximum size of an uncompressed sitemap in the sitemaps protocol.
+const maxSitemapSize = 50 * 1024 * 1024
+
+var (
+	errSitemapDisallowed = errors.New("the sitemap is disallowed by robots.txt")
+	errSitemapHalted     = errors.New("the crawl was halted before the sitemap was requested")
+)
+
+// crawlSitemaps crawls the internal pages listed in the sitemaps of the seed URLs'
+// sites as additional seeds. The sitemaps are discovered from the Sitemap lines in the
+// robots.txt file and from the default /sitemap.xml location, and sitemap indexes
+// are followed to the sitemaps they list. Pages that were already found during the
+// crawl are marked as being in the sitemap, the remaining pages are crawled at depth 0.
+// This should be called after the crawl of the seed URLs has finished so that pages
+// only found in the sitemap (orphan pages) can be identified. crawlSitemaps blocks
+// until the crawl has finished. No more sitemaps are requested once the crawl is
+// halted by one of its stop conditions.
+func (c *Crawler) crawlSitemaps(ctx context.Context) {
+	var (
+		found   = make([]discovery, 0)
+		origins = make(map[string]struct{})
+	)
+
+	for _, seedURL := range slices.All(c.seeds) {
+		if ctx.Err() != nil || c.halted() {
+			break
+		}
+
+		// Seed URLs on the same site share the same sitemaps.
+		origin := seedURL.Scheme + "://" + seedURL.Host
+		if _, ok := origins[origin]; ok {
+			continue
+		}
+
+		origins[origin] = struct{}{}
+
+		for _, rawURL := range slices.All(c.sitemapPageURLs(ctx, seedURL)) {
+			page, err := c.discover(ctx, rawURL, seedURL.String(), 0, true)
+			if err != nil {
+				c.logger.Warn("unable to record the sitemap page", slog.String("url", rawURL), slog.Any("error", err))
+
+				continue
+			}
+
+			if page.internal {
+				found = append(found, page)
+			}
+		}
+	}
+
+	c.mu.Lock()
+
+	// Pages that were already found are still marked as being in the
+	// sitemap after one of the crawl's limits is reached.
+	for ind := range len(found) {
+		c.recordVisit(found[ind])
+	}
+
+	c.mu.Unlock()
+
+	c.run(ctx)
+}
+
+// sitemapPageURLs returns the URLs of all the pages listed in the sitemaps of the seed URL's site.
+func (c *Crawler) sitemapPageURLs(ctx context.Context, seedURL *url.URL) []string {
+	origin := seedURL.Scheme + "://" + seedURL.Host
+
+	queue := c.robotsSitemaps(ctx, seedURL)
+
+	if defaultSitemap := origin + "/sitemap.xml"; !slices.Contains(queue, defaultSitemap) {
+		queue = append(queue, defaultSitemap)
+	}
+
+	var (
+		visited = make(map[string]struct{})
+		seen    = make(map[string]struct{})
+		pages   = make([]string, 0)
+	)
+
+	for len(queue) > 0 && ctx.Err() == nil && !c.halted() {
+		rawURL := queue[0]
+		queue = queue[1:]
+
+		if _, ok := visited[rawURL]; ok {
+			continue
+		}
+
+		visited[rawURL] = struct{}{}
+
+		doc, err := c.getSitemap(ctx, rawURL)
+		if errors.Is(err, errSitemapHalted) {
+			break
+		}
+
+		if err != nil {
+			// A missing sitemap is not worth a warning as it is optional.
+			var statusErr *statusError
+			if !errors.As(err, &statusErr) || statusErr.statusCode != http.StatusNotFound {
+				c.logger.Warn("unable to retrieve the sitemap", slog.String("url", rawURL), slog.Any("error", err))
+			}
+
+			continue
+		}
+
+		// Add the nested sitemaps from a sitemap index to the queue.
+		queue = append(queue, doc.Sitemaps...)
+
+		for _, pageURL := range slices.All(doc.URLs) {
+			if _, ok := seen[pageURL]; ok {
+				continue
+			}
+
+			seen[pageURL] = struct{}{}
+			pages = append(pages, pageURL)
+		}
+	}
+
+	return pages
+}
+
+// robotsSitemaps returns the sitemaps listed in the Sitemap lines of the robots.txt
+// file of the seed URL's site. The robots.txt file is still retrieved for its Sitemap
+// lines when the crawler is configured to ignore its rules.
+func (c *Crawler) robotsSitemaps(ctx context.Context, seedURL *url.URL) []string {
+	if !c.ignoreRobots {
+		return slices.Clone(c.robotsRules(ctx, seedURL).Sitemaps())
+	}
+
+	if err := c.limiter.wait(ctx, seedURL.Host, 0); err != nil || c.halted() {
+		return nil
+	}
+
+	rules, err := c.getRobots(ctx, seedURL.Scheme+"://"+seedURL.Host)
+	if err != nil {
+		c.logger.Debug("unable to retrieve the sitemaps from the robots.txt file", slog.String("url", seedURL.String()), slog.Any("error", err))
+
+		return nil
+	}
+
+	return slices.Clone(rules.Sitemaps())
+}
+
+// getSitemap retrieves and parses the sitemap at rawURL. The request is only
+// sent if it is allowed by the robots.txt rules of the sitemap's origin and if
+// the crawl was not halted while waiting for its turn with the host limiter.
+func (c *Crawler) getSitemap(ctx context.Context, rawURL string) (sitemap.Sitemap, error) {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return sitemap.Sitemap{}, fmt.Errorf("error parsing the URL %q: %w", rawURL, err)
+	}
+
+	rules := c.robotsRules(ctx, parsedURL)
+
+	if !rules.Allowed(parsedURL.RequestURI()) {
+		return sitemap.Sitemap{}, errSitemapDisallowed
+	}
+
+	if err := c.limiter.wait(ctx, parsedURL.Host, rules.CrawlDelay()); err != nil {
+		return sitemap.Sitemap{}, err
+	}
+
+	if c.halted() {
+		return sitemap.Sitemap{}, errSitemapHalted
+	}
+
+	resp, _, err := c.do(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return sitemap.Sitemap{}, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return sitemap.Sitemap{}, &statusError{
+			url:        rawURL,
+			statusCode: resp.StatusCode,
+			status:     resp.Status,
+			retryAfter: 0,
+		}
+	}
+
+	doc, err := sitemap.Parse(resp.Body, maxSitemapSize)
+	if err != nil {
+		return sitemap.Sitemap{}, fmt.Errorf("error parsing %s: %w", rawURL, err)
+	}
+
+	return doc, nil
+}
@@ -0,0 +1,90 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"testing"
+
+	"codeflow.dananglin.me.uk/apollo/web-crawler/crawler/crawlertest"
+)
+
+func TestSitemapsFromRobots(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name         string
+		ignoreRobots bool
+		wantFetched  []string
+	}{
+		{
+			name:         "Robots.txt rules respected",
+			ignoreRobots: false,
+			wantFetched:  []string{"example.com", "example.com/orphan"},
+		},
+		{
+			name:         "Robots.txt rules ignored",
+			ignoreRobots: true,
+			wantFetched:  []string{"example.com", "example.com/orphan", "example.com/private"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			fetcher := crawlertest.NewFetcher()
+			fetcher.Add("https://example.com/robots.txt", crawlertest.Response{
+				Status: http.StatusOK,
+				Header: http.Header{"Content-Type": []string{"text/plain"}},
+				Body:   "User-agent: *\nDisallow: /private\n\nSitemap: https://example.com/pages.xml\n",
+				Delay:  0,
+				Err:    nil,
+			})
+			fetcher.Add("https://example.com/pages.xml", crawlertest.Response{
+				Status: http.StatusOK,
+				Header: http.Header{"Content-Type": []string{"application/xml"}},
+				Body: `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+<url><loc>https://example.com/orphan</loc></url>
+<url><loc>https://example.com/private</loc></url>
+</urlset>`,
+				Delay: 0,
+				Err:   nil,
+			})
+			fetcher.AddHTML("https://example.com", `<html><body></body></html>`)
+			fetcher.AddHTML("https://example.com/orphan", `<html><body></body></html>`)
+			fetcher.AddHTML("https://example.com/private", `<html><body></body></html>`)
+
+			testCrawler, err := New(
+				[]string{"https://example.com"},
+				WithMaxWorkers(1),
+				WithIgnoreRobots(tc.ignoreRobots),
+				WithRetries(1, 0, 0),
+				WithSitemaps(true),
+				WithFetcher(fetcher),
+			)
+			if err != nil {
+				t.Fatalf("Test %q FAILED: unexpected error creating the crawler: %v", tc.name, err)
+			}
+
+			result, err := testCrawler.Run(context.Background())
+			if err != nil {
+				t.Fatalf("Test %q FAILED: unexpected error running the crawl: %v", tc.name, err)
+			}
+
+			fetched := make([]string, 0)
+
+			for _, record := range slices.All(result.Records) {
+				if record.Status == http.StatusOK {
+					fetched = append(fetched, record.Link)
+				}
+			}
+
+			slices.Sort(fetched)
+
+			if !slices.Equal(fetched, tc.wantFetched) {
+				t.Errorf("Test %q FAILED: unexpected pages fetched: want %v, got %v", tc.name, tc.wantFetched, fetched)
+			}
+		})
+	}
+}
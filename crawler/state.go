@@ -0,0 +1,507 @@
+package crawler
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// stateVersion is the version of the state file format.
+const stateVersion = 11
+
+var (
+	errNoStateFile    = errors.New("the state file is not configured")
+	errStateVersion   = errors.New("unsupported state file version")
+	errStateSeedsDiff = errors.New("the state file belongs to a crawl of different seed URLs")
+	errStateSyntax    = errors.New("unexpected token in the state file")
+)
+
+// task is an internal page that is waiting to be fetched.
+type task struct {
+	RawURL        string `json:"rawUrl"`
+	NormalisedURL string `json:"normalisedUrl"`
+	Depth         int    `json:"depth"`
+	FromSitemap   bool   `json:"fromSitemap"`
+	Seed          string `json:"seed"`
+}
+
+// crawlState is the state of a crawl that is saved to disk.
+// Queued tasks are the pages in the frontier that have not been fetched yet and
+// in progress tasks are the pages that were being fetched when the state was
+// saved. All of these pages are already recorded in the page store. The pages are
+// not part of the struct; they are written one at a time after the rest of the
+// state in the "pages" object of the state file.
+type crawlState struct {
+	Version    int             `json:"version"`
+	Seeds      []string        `json:"seeds"`
+	SavedAt    time.Time       `json:"savedAt"`
+	Queued     []task          `json:"queued"`
+	InProgress map[string]task `json:"inProgress"`
+	StopReason StopReason      `json:"stopReason"`
+}
+
+// statePagesKey is the key of the object of the saved pages in the state file.
+const statePagesKey = "pages"
+
+// savedPage is the saved form of a pageStat.
+type savedPage struct {
+	Count       int           `json:"count"`
+	Internal    bool          `json:"internal"`
+	Subdomain   bool          `json:"subdomain"`
+	ScopeHost   bool          `json:"scopeHost"`
+	Depth       int           `json:"depth"`
+	Disallowed  bool          `json:"disallowed"`
+	Excluded    bool          `json:"excluded"`
+	Seed        string        `json:"seed"`
+	Attempts    int           `json:"attempts"`
+	Fetched     bool          `json:"fetched"`
+	Linked      bool          `json:"linked"`
+	InSitemap   bool          `json:"inSitemap"`
+	Scheduled   bool          `json:"scheduled"`
+	Referrers   []Referrer    `json:"referrers,omitempty"`
+	RawURL      string        `json:"rawUrl"`
+	Checked     bool          `json:"checked"`
+	Status      int           `json:"status"`
+	ErrorClass  string        `json:"errorClass"`
+	ContentType string        `json:"contentType"`
+	Size        int64         `json:"size"`
+	Latency     time.Duration `json:"latency"`
+	FetchError  string        `json:"fetchError"`
+
+	Redirects       []RedirectHop `json:"redirects,omitempty"`
+	RedirectsTo     string        `json:"redirectsTo"`
+	RedirectLoop    bool          `json:"redirectLoop"`
+	RedirectStopped string        `json:"redirectStopped"`
+	RedirectedFrom  string        `json:"redirectedFrom"`
+
+	Canonical          string   `json:"canonical"`
+	CanonicalOtherHost bool     `json:"canonicalOtherHost"`
+	Variants           []string `json:"variants,omitempty"`
+
+	NoIndex       bool `json:"noIndex"`
+	NoFollow      bool `json:"noFollow"`
+	NofollowLinks int  `json:"nofollowLinks"`
+	NofollowOnly  bool `json:"nofollowOnly"`
+}
+
+// runCheckpoints periodically saves the state of the crawl to the state file
+// until the context is cancelled.
+func (c *Crawler) runCheckpoints(ctx context.Context, interval time.Duration) {
+	if c.stateFile == "" || interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.saveState(); err != nil {
+				c.logger.Warn("unable to save the state of the crawl", slog.Any("error", err))
+			}
+		}
+	}
+}
+
+// saveState saves the state of the crawl to the state file. The state is written
+// to a temporary file first which then replaces the state file so that a crash
+// while saving does not corrupt the previous state. The crawler's lock is only
+// held while the snapshot of the state is taken; the pages are encoded from the
+// snapshot while the crawl carries on.
+func (c *Crawler) saveState() error {
+	if c.stateFile == "" {
+		return errNoStateFile
+	}
+
+	c.mu.Lock()
+
+	queued, inFlight := c.frontier.snapshot()
+
+	state := crawlState{
+		Version:    stateVersion,
+		Seeds:      c.seedURLs(),
+		SavedAt:    time.Now().UTC(),
+		Queued:     queued,
+		InProgress: inFlight,
+		StopReason: c.stopReason,
+	}
+
+	pages, release := c.pages.snapshot()
+
+	c.mu.Unlock()
+
+	defer release()
+
+	tempFile, err := os.CreateTemp(filepath.Dir(c.stateFile), filepath.Base(c.stateFile)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("error creating the temporary state file: %w", err)
+	}
+
+	defer os.Remove(tempFile.Name())
+
+	writer := bufio.NewWriter(tempFile)
+
+	if err := encodeState(writer, state, pages); err != nil {
+		tempFile.Close()
+
+		return err
+	}
+
+	if err := c.pages.err(); err != nil {
+		tempFile.Close()
+
+		return fmt.Errorf("error reading the pages: %w", err)
+	}
+
+	if err := writer.Flush(); err != nil {
+		tempFile.Close()
+
+		return fmt.Errorf("error writing to %s: %w", tempFile.Name(), err)
+	}
+
+	if err := tempFile.Close(); err != nil {
+		return fmt.Errorf("error closing %s: %w", tempFile.Name(), err)
+	}
+
+	if err := os.Rename(tempFile.Name(), c.stateFile); err != nil {
+		return fmt.Errorf("error replacing %s: %w", c.stateFile, err)
+	}
+
+	return nil
+}
+
+// encodeState writes the state as a JSON object followed by the pages which are
+// encoded one at a time. The pages are written last so that the rest of the
+// state can be checked before they are loaded.
+func encodeState(writer *bufio.Writer, state crawlState, pages iter.Seq2[string, pageStat]) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("error marshalling the state to JSON: %w", err)
+	}
+
+	// Reopen the object to add the pages.
+	writer.Write(bytes.TrimSuffix(data, []byte("}")))
+	writer.WriteString(`,"` + statePagesKey + `":{`)
+
+	encoder := json.NewEncoder(writer)
+	first := true
+
+	for link, stat := range pages {
+		if !first {
+			writer.WriteString(",")
+		}
+
+		first = false
+
+		if err := encoder.Encode(link); err != nil {
+			return fmt.Errorf("error marshalling %s to JSON: %w", link, err)
+		}
+
+		writer.WriteString(":")
+
+		if err := encoder.Encode(newSavedPage(stat)); err != nil {
+			return fmt.Errorf("error marshalling %s to JSON: %w", link, err)
+		}
+	}
+
+	writer.WriteString("}}")
+
+	return nil
+}
+
+// loadState loads the state of a previous crawl from the state file.
+// This is called by Run before the crawl is resumed. The pages are decoded
+// from the file and added to the page store one at a time.
+func (c *Crawler) loadState() error {
+	if c.stateFile == "" {
+		return errNoStateFile
+	}
+
+	file, err := os.Open(c.stateFile)
+	if err != nil {
+		return fmt.Errorf("error opening %s: %w", c.stateFile, err)
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(bufio.NewReader(file))
+
+	state, err := decodeState(decoder)
+	if err != nil {
+		return fmt.Errorf("error decoding the state from %s: %w", c.stateFile, err)
+	}
+
+	if state.Version != stateVersion {
+		return fmt.Errorf("%w: want %d, got %d", errStateVersion, stateVersion, state.Version)
+	}
+
+	if seeds := c.seedURLs(); !slices.Equal(state.Seeds, seeds) {
+		return fmt.Errorf("%w: want %v, got %v", errStateSeedsDiff, seeds, state.Seeds)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.pages.clear()
+	c.scheduledPages = 0
+	c.externalLinks = 0
+	c.fetchedPages = 0
+	c.fetchErrors = 0
+	c.fetchedBytes = 0
+
+	err = decodeSavedPages(decoder, func(link string, saved savedPage) {
+		stat := saved.pageStat()
+
+		// The destination of a redirect from a page that was in flight is
+		// reached again when its source is fetched again, and its links are
+		// only recorded then.
+		if _, inProgress := state.InProgress[saved.RedirectedFrom]; saved.RedirectedFrom != "" && inProgress {
+			stat.fetched = false
+			stat.redirectedFrom = ""
+			stat.attempts = 0
+			stat.status = 0
+			stat.contentType = ""
+			stat.size = 0
+			stat.latency = 0
+		}
+
+		c.pages.put(link, stat)
+
+		switch {
+		case saved.Scheduled:
+			c.scheduledPages++
+		case !saved.Internal:
+			c.externalLinks++
+		}
+
+		// The pages that were in flight are counted when they are fetched again
+		// and the pages reached through a redirect were counted with their source.
+		if _, inProgress := state.InProgress[link]; saved.Attempts > 0 && saved.RedirectedFrom == "" && !inProgress {
+			c.fetchedPages++
+			c.fetchedBytes += saved.Size
+
+			if saved.FetchError != "" {
+				c.fetchErrors++
+			}
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("error decoding the pages from %s: %w", c.stateFile, err)
+	}
+
+	c.stopReason = state.StopReason
+
+	// The pages that were in flight are fetched again.
+	for link := range maps.Keys(state.InProgress) {
+		stat, _ := c.pages.get(link)
+		stat.fetched = false
+		c.pages.put(link, stat)
+	}
+
+	// The pages that were in flight are fetched first as they were taken
+	// from the front of the queue.
+	inProgress := slices.SortedFunc(maps.Values(state.InProgress), func(a, b task) int {
+		return cmp.Compare(a.Depth, b.Depth)
+	})
+
+	c.frontier = newFrontier()
+	c.frontier.push(inProgress...)
+	c.frontier.push(state.Queued...)
+
+	// The crawl stays halted if a stop condition counted from the pages is
+	// still reached so that no more pages are fetched when it is resumed.
+	c.checkStopConditions()
+
+	return nil
+}
+
+// decodeState decodes the state from the start of the state file up to the
+// object of the saved pages, which is left in the decoder.
+func decodeState(decoder *json.Decoder) (crawlState, error) {
+	var state crawlState
+
+	if err := expectDelim(decoder, '{'); err != nil {
+		return state, err
+	}
+
+	fields := make(map[string]json.RawMessage)
+
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return state, fmt.Errorf("error reading the key of a field: %w", err)
+		}
+
+		key, ok := token.(string)
+		if !ok {
+			return state, fmt.Errorf("%w: got %v", errStateSyntax, token)
+		}
+
+		if key == statePagesKey {
+			break
+		}
+
+		var value json.RawMessage
+
+		if err := decoder.Decode(&value); err != nil {
+			return state, fmt.Errorf("error reading the field %s: %w", key, err)
+		}
+
+		fields[key] = value
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return state, fmt.Errorf("error marshalling the fields of the state: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &state); err != nil {
+		return state, fmt.Errorf("error unmarshalling the state: %w", err)
+	}
+
+	return state, nil
+}
+
+// decodeSavedPages decodes the saved pages from the decoder one at a time and
+// passes each of them to the load function.
+func decodeSavedPages(decoder *json.Decoder, load func(link string, saved savedPage)) error {
+	if err := expectDelim(decoder, '{'); err != nil {
+		return err
+	}
+
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return fmt.Errorf("error reading the link of a page: %w", err)
+		}
+
+		link, ok := token.(string)
+		if !ok {
+			return fmt.Errorf("%w: got %v", errStateSyntax, token)
+		}
+
+		var saved savedPage
+
+		if err := decoder.Decode(&saved); err != nil {
+			return fmt.Errorf("error reading the page %s: %w", link, err)
+		}
+
+		load(link, saved)
+	}
+
+	return expectDelim(decoder, '}')
+}
+
+// expectDelim reads the next token from the decoder and checks that it is
+// the given delimiter.
+func expectDelim(decoder *json.Decoder, delim json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return fmt.Errorf("error reading the state: %w", err)
+	}
+
+	if token != delim {
+		return fmt.Errorf("%w: want %v, got %v", errStateSyntax, delim, token)
+	}
+
+	return nil
+}
+
+// newSavedPage returns the saved form of the page.
+func newSavedPage(stat pageStat) savedPage {
+	return savedPage{
+		Count:       stat.count,
+		Internal:    stat.internal,
+		Subdomain:   stat.subdomain,
+		ScopeHost:   stat.scopeHost,
+		Depth:       stat.depth,
+		Disallowed:  stat.disallowed,
+		Excluded:    stat.excluded,
+		Seed:        stat.seed,
+		Attempts:    stat.attempts,
+		Fetched:     stat.fetched,
+		Linked:      stat.linked,
+		InSitemap:   stat.inSitemap,
+		Scheduled:   stat.scheduled,
+		Referrers:   stat.referrers,
+		RawURL:      stat.rawURL,
+		Checked:     stat.checked,
+		Status:      stat.status,
+		ErrorClass:  stat.errorClass,
+		ContentType: stat.contentType,
+		Size:        stat.size,
+		Latency:     stat.latency,
+		FetchError:  stat.fetchError,
+
+		Redirects:       stat.redirects,
+		RedirectsTo:     stat.redirectsTo,
+		RedirectLoop:    stat.redirectLoop,
+		RedirectStopped: stat.redirectStopped,
+		RedirectedFrom:  stat.redirectedFrom,
+
+		Canonical:          stat.canonical,
+		CanonicalOtherHost: stat.canonicalOtherHost,
+		Variants:           stat.variants,
+
+		NoIndex:       stat.noIndex,
+		NoFollow:      stat.noFollow,
+		NofollowLinks: stat.nofollowLinks,
+		NofollowOnly:  stat.nofollowOnly,
+	}
+}
+
+// pageStat returns the page from its saved form.
+func (saved savedPage) pageStat() pageStat {
+	return pageStat{
+		count:       saved.Count,
+		internal:    saved.Internal,
+		subdomain:   saved.Subdomain,
+		scopeHost:   saved.ScopeHost,
+		depth:       saved.Depth,
+		disallowed:  saved.Disallowed,
+		excluded:    saved.Excluded,
+		seed:        saved.Seed,
+		attempts:    saved.Attempts,
+		fetched:     saved.Fetched,
+		linked:      saved.Linked,
+		inSitemap:   saved.InSitemap,
+		scheduled:   saved.Scheduled,
+		referrers:   saved.Referrers,
+		rawURL:      saved.RawURL,
+		checked:     saved.Checked,
+		status:      saved.Status,
+		errorClass:  saved.ErrorClass,
+		contentType: saved.ContentType,
+		size:        saved.Size,
+		latency:     saved.Latency,
+		fetchError:  saved.FetchError,
+
+		redirects:       saved.Redirects,
+		redirectsTo:     saved.RedirectsTo,
+		redirectLoop:    saved.RedirectLoop,
+		redirectStopped: saved.RedirectStopped,
+		redirectedFrom:  saved.RedirectedFrom,
+
+		canonical:          saved.Canonical,
+		canonicalOtherHost: saved.CanonicalOtherHost,
+		variants:           saved.Variants,
+
+		noIndex:       saved.NoIndex,
+		noFollow:      saved.NoFollow,
+		nofollowLinks: saved.NofollowLinks,
+		nofollowOnly:  saved.NofollowOnly,
+	}
+}
@@ -0,0 +1,233 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"codeflow.dananglin.me.uk/apollo/web-crawler/crawler/crawlertest"
+)
+
+func TestSaveAndLoadState(t *testing.T) {
+	t.Parallel()
+
+	options := []Option{
+		WithMaxWorkers(1),
+		WithRetries(1, 0, 0),
+		WithStateFile(filepath.Join(t.TempDir(), "state.json"), 0),
+	}
+
+	testSeeds := []string{"https://example.org"}
+
+	savedCrawler, err := New(testSeeds, options...)
+	if err != nil {
+		t.Fatalf("Test 'TestSaveAndLoadState' FAILED: unexpected error creating the crawler: %v", err)
+	}
+
+	savedCrawler.pages = memoryStore{
+		"example.org":          {count: 3, internal: true, depth: 0, attempts: 1, linked: true, scheduled: true},
+		"example.org/admin":    {count: 1, internal: true, depth: 1, disallowed: true, linked: true},
+		"example.org/blog":     {count: 2, internal: true, depth: 1, linked: true, scheduled: true},
+		"example.org/orphan":   {count: 0, internal: true, depth: 0, inSitemap: true},
+		"github.com/dananglin": {count: 1, internal: false, depth: 1, linked: true},
+	}
+
+	inProgressTask := task{
+		RawURL:        "https://example.org/blog",
+		NormalisedURL: "example.org/blog",
+		Depth:         1,
+		FromSitemap:   false,
+	}
+
+	queuedTask := task{
+		RawURL:        "https://example.org/blog/first-post",
+		NormalisedURL: "example.org/blog/first-post",
+		Depth:         2,
+		FromSitemap:   false,
+	}
+
+	// Move the first task to the in-flight tasks.
+	savedCrawler.frontier.push(inProgressTask, queuedTask)
+
+	if _, ok := savedCrawler.frontier.pop(context.Background()); !ok {
+		t.Fatalf("Test 'TestSaveAndLoadState' FAILED: unable to pop the task from the frontier")
+	}
+
+	if err := savedCrawler.saveState(); err != nil {
+		t.Fatalf("Test 'TestSaveAndLoadState' FAILED: unexpected error saving the state: %v", err)
+	}
+
+	loadedCrawler, err := New(testSeeds, options...)
+	if err != nil {
+		t.Fatalf("Test 'TestSaveAndLoadState' FAILED: unexpected error creating the crawler: %v", err)
+	}
+
+	if err := loadedCrawler.loadState(); err != nil {
+		t.Fatalf("Test 'TestSaveAndLoadState' FAILED: unexpected error loading the state: %v", err)
+	}
+
+	if !reflect.DeepEqual(loadedCrawler.pages, savedCrawler.pages) {
+		t.Errorf(
+			"Test 'TestSaveAndLoadState' FAILED: unexpected pages loaded: want %v, got %v",
+			savedCrawler.pages,
+			loadedCrawler.pages,
+		)
+	}
+
+	// The budgets are restored from the loaded pages.
+	if loadedCrawler.scheduledPages != 2 || loadedCrawler.externalLinks != 1 {
+		t.Errorf(
+			"Test 'TestSaveAndLoadState' FAILED: unexpected budgets loaded: want 2 pages and 1 external link, got %d pages and %d external links",
+			loadedCrawler.scheduledPages,
+			loadedCrawler.externalLinks,
+		)
+	}
+
+	// The in-flight task is queued before the other tasks when the state is loaded.
+	wantQueue := []task{inProgressTask, queuedTask}
+
+	gotQueue, gotInFlight := loadedCrawler.frontier.snapshot()
+
+	if !reflect.DeepEqual(gotQueue, wantQueue) || len(gotInFlight) != 0 {
+		t.Errorf(
+			"Test 'TestSaveAndLoadState' FAILED: unexpected frontier loaded: want %v, got %v (in flight: %v)",
+			wantQueue,
+			gotQueue,
+			gotInFlight,
+		)
+	}
+
+	otherCrawler, err := New([]string{"https://example.org", "https://example.com"}, options...)
+	if err != nil {
+		t.Fatalf("Test 'TestSaveAndLoadState' FAILED: unexpected error creating the crawler: %v", err)
+	}
+
+	if err := otherCrawler.loadState(); err == nil {
+		t.Errorf("Test 'TestSaveAndLoadState' FAILED: expected an error loading the state of different seed URLs")
+	}
+}
+
+func TestResumeHaltedCrawl(t *testing.T) {
+	t.Parallel()
+
+	// The pages linked from the home page are missing so the crawl is halted
+	// after the first of them could not be fetched.
+	newFetcher := func() *crawlertest.Fetcher {
+		fetcher := crawlertest.NewFetcher()
+		fetcher.AddHTML("https://example.com", `<html><body>`+
+			`<a href="/page-1">Page</a><a href="/page-2">Page</a><a href="/page-3">Page</a>`+
+			`</body></html>`)
+
+		return fetcher
+	}
+
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+
+	newTestCrawler := func(fetcher *crawlertest.Fetcher, resume bool) *Crawler {
+		testCrawler, err := New(
+			[]string{"https://example.com"},
+			WithMaxWorkers(1),
+			WithMaxErrors(1),
+			WithIgnoreRobots(true),
+			WithRetries(1, 0, 0),
+			WithStateFile(stateFile, 0),
+			WithResume(resume),
+			WithFetcher(fetcher),
+		)
+		if err != nil {
+			t.Fatalf("Test 'TestResumeHaltedCrawl' FAILED: unexpected error creating the crawler: %v", err)
+		}
+
+		return testCrawler
+	}
+
+	if _, err := newTestCrawler(newFetcher(), false).Run(context.Background()); err != nil {
+		t.Fatalf("Test 'TestResumeHaltedCrawl' FAILED: unexpected error running the crawl: %v", err)
+	}
+
+	fetcher := newFetcher()
+
+	result, err := newTestCrawler(fetcher, true).Run(context.Background())
+	if err != nil {
+		t.Fatalf("Test 'TestResumeHaltedCrawl' FAILED: unexpected error resuming the crawl: %v", err)
+	}
+
+	if requests := fetcher.Requests(); len(requests) != 0 {
+		t.Errorf("Test 'TestResumeHaltedCrawl' FAILED: unexpected requests after resuming the halted crawl: %v", requests)
+	}
+
+	if summary := result.Summary; summary.StopReason != StopReasonMaxErrors || summary.PagesFetched != 2 || summary.Errors != 1 {
+		t.Errorf(
+			"Test 'TestResumeHaltedCrawl' FAILED: unexpected summary: want %s, 2 pages fetched and 1 error, got %s, %d pages fetched and %d errors",
+			StopReasonMaxErrors,
+			summary.StopReason,
+			summary.PagesFetched,
+			summary.Errors,
+		)
+	}
+}
+
+func TestResumeMidRedirect(t *testing.T) {
+	t.Parallel()
+
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+
+	savedCrawler, err := New([]string{"https://example.com"}, WithMaxWorkers(1), WithRetries(1, 0, 0), WithStateFile(stateFile, 0))
+	if err != nil {
+		t.Fatalf("Test 'TestResumeMidRedirect' FAILED: unexpected error creating the crawler: %v", err)
+	}
+
+	// The state is saved while /old is in flight, after its redirect to /new was
+	// followed but before the links on /new were recorded.
+	savedCrawler.pages = memoryStore{
+		"example.com": {count: 1, internal: true, attempts: 1, linked: true, scheduled: true, fetched: true, status: 200},
+		"example.com/old": {
+			count: 1, internal: true, depth: 1, attempts: 1, linked: true, scheduled: true, fetched: true, status: 301,
+		},
+		"example.com/new": {
+			internal: true, depth: 1, attempts: 1, scheduled: true, fetched: true, status: 200, redirectedFrom: "example.com/old",
+		},
+	}
+
+	savedCrawler.frontier.push(task{RawURL: "https://example.com/old", NormalisedURL: "example.com/old", Depth: 1, FromSitemap: false})
+
+	if _, ok := savedCrawler.frontier.pop(context.Background()); !ok {
+		t.Fatalf("Test 'TestResumeMidRedirect' FAILED: unable to pop the task from the frontier")
+	}
+
+	if err := savedCrawler.saveState(); err != nil {
+		t.Fatalf("Test 'TestResumeMidRedirect' FAILED: unexpected error saving the state: %v", err)
+	}
+
+	fetcher := crawlertest.NewFetcher()
+	fetcher.AddRedirect("https://example.com/old", http.StatusMovedPermanently, "/new")
+	fetcher.AddHTML("https://example.com/new", `<html><body><a href="/new/child">Child</a></body></html>`)
+	fetcher.AddHTML("https://example.com/new/child", `<html><body></body></html>`)
+
+	resumedCrawler, err := New(
+		[]string{"https://example.com"},
+		WithMaxWorkers(1),
+		WithIgnoreRobots(true),
+		WithRetries(1, 0, 0),
+		WithStateFile(stateFile, 0),
+		WithResume(true),
+		WithFetcher(fetcher),
+	)
+	if err != nil {
+		t.Fatalf("Test 'TestResumeMidRedirect' FAILED: unexpected error creating the crawler: %v", err)
+	}
+
+	if _, err := resumedCrawler.Run(context.Background()); err != nil {
+		t.Fatalf("Test 'TestResumeMidRedirect' FAILED: unexpected error resuming the crawl: %v", err)
+	}
+
+	if target := resumedCrawler.page("example.com/new"); !target.fetched || target.redirectedFrom != "example.com/old" {
+		t.Errorf("Test 'TestResumeMidRedirect' FAILED: the redirect to /new was not followed again: got %+v", target)
+	}
+
+	if child := resumedCrawler.page("example.com/new/child"); child.attempts != 1 {
+		t.Errorf("Test 'TestResumeMidRedirect' FAILED: the links on /new were not recorded: got %+v (requests: %v)", child, fetcher.Requests())
+	}
+}
@@ -0,0 +1,82 @@
+package crawler
+
+import (
+	"iter"
+	"maps"
+)
+
+// pageStore stores the pages found during the crawl keyed by their normalised URL.
+// The store is only accessed while holding the crawler's lock, except for err.
+// A store that fails to read or write a page records the first error, which is
+// returned by err, and carries on as if the page was not stored.
+type pageStore interface {
+	// get returns the page and true if the page is in the store.
+	get(normalisedURL string) (pageStat, bool)
+
+	// put adds the page to the store or replaces it.
+	put(normalisedURL string, stat pageStat)
+
+	// len returns the number of pages in the store.
+	len() int
+
+	// all returns an iterator over the pages in the store. The store can be
+	// modified while iterating but the modified pages may not be visited.
+	all() iter.Seq2[string, pageStat]
+
+	// snapshot returns an iterator over the pages as they are when the snapshot
+	// is taken and a function that releases it. The snapshot can be read without
+	// holding the crawler's lock while the store is modified.
+	snapshot() (iter.Seq2[string, pageStat], func())
+
+	// clear removes all the pages from the store.
+	clear()
+
+	// err returns the first error that occurred while reading or writing a page.
+	err() error
+
+	// close releases the resources held by the store.
+	close() error
+}
+
+// memoryStore is the default store which keeps the pages in memory.
+type memoryStore map[string]pageStat
+
+func newMemoryStore() memoryStore {
+	return make(memoryStore)
+}
+
+func (s memoryStore) get(normalisedURL string) (pageStat, bool) {
+	stat, exists := s[normalisedURL]
+
+	return stat, exists
+}
+
+func (s memoryStore) put(normalisedURL string, stat pageStat) {
+	s[normalisedURL] = stat
+}
+
+func (s memoryStore) len() int {
+	return len(s)
+}
+
+func (s memoryStore) all() iter.Seq2[string, pageStat] {
+	return maps.All(s)
+}
+
+// snapshot copies the pages. This is much quicker than encoding them so the
+// copy can be made while holding the crawler's lock.
+func (s memoryStore) snapshot() (iter.Seq2[string, pageStat], func()) {
+	return maps.All(maps.Clone(s)), func() {}
+}
+
+func (s memoryStore) clear() {
+	clear(s)
+}
+
+func (s memoryStore) err() error {
+	return nil
+}
+
+func (s memoryStore) close() error {
+	return nil
+}
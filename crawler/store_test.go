@@ -0,0 +1,219 @@
+package crawler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"codeflow.dananglin.me.uk/apollo/web-crawler/crawler/crawlertest"
+)
+
+// page returns the page from the crawler's page store.
+func (c *Crawler) page(normalisedURL string) pageStat {
+	stat, _ := c.pages.get(normalisedURL)
+
+	return stat
+}
+
+func TestPageStores(t *testing.T) {
+	t.Parallel()
+
+	stores := map[string]func(t *testing.T) pageStore{
+		"memory": func(*testing.T) pageStore {
+			return newMemoryStore()
+		},
+		"disk": func(t *testing.T) pageStore {
+			store, err := openDiskStore(filepath.Join(t.TempDir(), "pages.db"))
+			if err != nil {
+				t.Fatalf("Test 'TestPageStores' FAILED: unexpected error opening the disk store: %v", err)
+			}
+
+			t.Cleanup(func() { store.close() })
+
+			return store
+		},
+	}
+
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			store := newStore(t)
+
+			want := pageStat{
+				count:     2,
+				internal:  true,
+				depth:     1,
+				attempts:  1,
+				status:    301,
+				referrers: []Referrer{{Source: "https://example.com", AnchorText: "About"}},
+				redirects: []RedirectHop{{URL: "https://example.com/about", Status: 301}},
+				variants:  []string{"example.com/about/amp"},
+				seed:      "https://example.com",
+			}
+
+			if _, exists := store.get("example.com/about"); exists {
+				t.Errorf("Test 'TestPageStores' FAILED: found a page in the empty %s store", name)
+			}
+
+			store.put("example.com/about", want)
+
+			if got, exists := store.get("example.com/about"); !exists || !reflect.DeepEqual(got, want) {
+				t.Errorf("Test 'TestPageStores' FAILED: unexpected page in the %s store: want %+v, got %+v", name, want, got)
+			}
+
+			// Add enough pages to iterate over more than one batch of the disk store.
+			total := 2*diskStoreBatchSize + 1
+
+			for ind := range total - 1 {
+				store.put(fmt.Sprintf("example.com/%05d", ind), pageStat{count: ind})
+			}
+
+			if got := store.len(); got != total {
+				t.Errorf("Test 'TestPageStores' FAILED: unexpected number of pages in the %s store: want %d, got %d", name, total, got)
+			}
+
+			keys := make([]string, 0, total)
+
+			for key, stat := range store.all() {
+				keys = append(keys, key)
+
+				// Modifying the store while iterating is allowed.
+				stat.checked = true
+				store.put(key, stat)
+			}
+
+			if len(keys) != total || len(slices.Compact(slices.Sorted(slices.Values(keys)))) != total {
+				t.Errorf("Test 'TestPageStores' FAILED: unexpected iteration over the %s store: got %d keys", name, len(keys))
+			}
+
+			// Replacing the pages, whether they are already written to the disk
+			// store or still buffered, does not add to the number of pages.
+			if got := store.len(); got != total {
+				t.Errorf(
+					"Test 'TestPageStores' FAILED: unexpected number of pages after the %s store was updated: want %d, got %d",
+					name,
+					total,
+					got,
+				)
+			}
+
+			if got, _ := store.get("example.com/00042"); !got.checked || got.count != 42 {
+				t.Errorf("Test 'TestPageStores' FAILED: the page was not updated while iterating over the %s store: got %+v", name, got)
+			}
+
+			// The snapshot is not changed by the writes made after it was taken.
+			pages, release := store.snapshot()
+
+			store.put("example.com/00042", pageStat{count: 0})
+			store.put("example.com/added", pageStat{count: 1})
+
+			snapshot := make(map[string]pageStat)
+
+			for key, stat := range pages {
+				snapshot[key] = stat
+			}
+
+			release()
+
+			if _, added := snapshot["example.com/added"]; len(snapshot) != total || added || snapshot["example.com/00042"].count != 42 {
+				t.Errorf("Test 'TestPageStores' FAILED: the snapshot of the %s store changed after it was taken: got %d pages", name, len(snapshot))
+			}
+
+			store.clear()
+
+			if got := store.len(); got != 0 {
+				t.Errorf("Test 'TestPageStores' FAILED: the %s store was not cleared: got %d pages", name, got)
+			}
+
+			if err := store.err(); err != nil {
+				t.Errorf("Test 'TestPageStores' FAILED: unexpected error from the %s store: %v", name, err)
+			}
+		})
+	}
+}
+
+func TestCrawlWithStoreFile(t *testing.T) {
+	t.Parallel()
+
+	fetcher := crawlertest.NewFetcher()
+	fetcher.AddHTML("https://example.com", `<html><body>
+<a href="/about">About</a>
+<a href="/blog">Blog</a>
+<a href="https://example.org">Example</a>
+</body></html>`)
+	fetcher.AddHTML("https://example.com/about", `<html><body><a href="/">Home</a><a href="/blog">Blog</a></body></html>`)
+	fetcher.AddHTML("https://example.com/blog", `<html><body><a href="/blog/post">Post</a></body></html>`)
+
+	// run returns the result of the crawl and the result read back from its JSON report.
+	run := func(options ...Option) (Result, Result) {
+		options = append(options, WithMaxWorkers(1), WithIgnoreRobots(true), WithRetries(1, 0, 0), WithFetcher(fetcher))
+
+		testCrawler, err := New([]string{"https://example.com"}, options...)
+		if err != nil {
+			t.Fatalf("Test 'TestCrawlWithStoreFile' FAILED: unexpected error creating the crawler: %v", err)
+		}
+
+		defer testCrawler.Close()
+
+		result, err := testCrawler.Run(context.Background())
+		if err != nil {
+			t.Fatalf("Test 'TestCrawlWithStoreFile' FAILED: unexpected error running the crawl: %v", err)
+		}
+
+		var (
+			output strings.Builder
+			report Result
+		)
+
+		if err := testCrawler.WriteReport(&output, "", "json", false); err != nil {
+			t.Fatalf("Test 'TestCrawlWithStoreFile' FAILED: unexpected error writing the report: %v", err)
+		}
+
+		if err := json.Unmarshal([]byte(output.String()), &report); err != nil {
+			t.Fatalf("Test 'TestCrawlWithStoreFile' FAILED: unexpected error decoding the report: %v", err)
+		}
+
+		// The duration of the crawl differs between the runs.
+		result.Summary.DurationMs = 0
+		report.Summary.DurationMs = 0
+
+		return result, report
+	}
+
+	storeFile := filepath.Join(t.TempDir(), "pages.db")
+
+	want, wantReport := run()
+	got, gotReport := run(WithStoreFile(storeFile))
+
+	if !reflect.DeepEqual(wantReport, want) {
+		t.Errorf("Test 'TestCrawlWithStoreFile' FAILED: the report differs from the result: want %+v, got %+v", want, wantReport)
+	}
+
+	if got.Records != nil || !reflect.DeepEqual(got.Summary, want.Summary) {
+		t.Errorf(
+			"Test 'TestCrawlWithStoreFile' FAILED: unexpected result of the crawl kept in the store file: "+
+				"want the summary %+v without records, got %+v",
+			want.Summary,
+			got,
+		)
+	}
+
+	if len(gotReport.Records) != 5 {
+		t.Errorf("Test 'TestCrawlWithStoreFile' FAILED: unexpected number of records: want 5, got %d", len(gotReport.Records))
+	}
+
+	if !reflect.DeepEqual(gotReport, want) {
+		t.Errorf("Test 'TestCrawlWithStoreFile' FAILED: the report differs from the in-memory crawl: want %+v, got %+v", want, gotReport)
+	}
+
+	// The pages of the previous crawl are removed when the store is reused.
+	if _, again := run(WithStoreFile(storeFile)); !reflect.DeepEqual(again, want) {
+		t.Errorf("Test 'TestCrawlWithStoreFile' FAILED: the reused store kept the previous pages: got %+v", again)
+	}
+}
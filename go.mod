@@ -2,4 +2,9 @@ module codeflow.dananglin.me.uk/apollo/web-crawler
 
 go 1.23.0
 
-require golang.org/x/net v0.28.0
+require (
+	go.etcd.io/bbolt v1.4.3
+	golang.org/x/net v0.28.0
+)
+
+require golang.org/x/sys v0.29.0 // indirect
@@ -0,0 +1,151 @@
+// Package filter decides which URLs are in scope for a crawl based on
+// include and exclude patterns.
+//
+// A pattern that starts with "re:" is a regular expression that is matched
+// against the full URL. Any other pattern is a glob that is matched against
+// the whole path of the URL followed by its query string, if it has one.
+// In a glob "*" matches any sequence of characters except "/" and "**"
+// matches any sequence of characters including "/".
+package filter
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+const regexPrefix = "re:"
+
+var errEmptyPattern = errors.New("the pattern is empty")
+
+// Filter evaluates URLs against a list of include and exclude patterns.
+// The zero value allows every URL.
+type Filter struct {
+	include []pattern
+	exclude []pattern
+}
+
+type pattern struct {
+	regex   *regexp.Regexp
+	fullURL bool
+}
+
+// New returns a filter for the given include and exclude patterns.
+func New(include, exclude []string) (Filter, error) {
+	includePatterns, err := compileAll(include)
+	if err != nil {
+		return Filter{}, fmt.Errorf("invalid include pattern: %w", err)
+	}
+
+	excludePatterns, err := compileAll(exclude)
+	if err != nil {
+		return Filter{}, fmt.Errorf("invalid exclude pattern: %w", err)
+	}
+
+	filter := Filter{
+		include: includePatterns,
+		exclude: excludePatterns,
+	}
+
+	return filter, nil
+}
+
+// Allowed evaluates to true if the URL matches at least one of the include
+// patterns (or if there are none) and does not match any of the exclude patterns.
+func (f Filter) Allowed(u *url.URL) bool {
+	if len(f.include) > 0 && !slices.ContainsFunc(f.include, func(p pattern) bool { return p.match(u) }) {
+		return false
+	}
+
+	return !slices.ContainsFunc(f.exclude, func(p pattern) bool { return p.match(u) })
+}
+
+func compileAll(values []string) ([]pattern, error) {
+	patterns := make([]pattern, 0, len(values))
+
+	for _, value := range slices.All(values) {
+		compiled, err := compile(value)
+		if err != nil {
+			return nil, err
+		}
+
+		patterns = append(patterns, compiled)
+	}
+
+	return patterns, nil
+}
+
+func compile(value string) (pattern, error) {
+	if value == "" || value == regexPrefix {
+		return pattern{}, errEmptyPattern
+	}
+
+	if expr, ok := strings.CutPrefix(value, regexPrefix); ok {
+		regex, err := regexp.Compile(expr)
+		if err != nil {
+			return pattern{}, fmt.Errorf("error compiling %q: %w", value, err)
+		}
+
+		return pattern{regex: regex, fullURL: true}, nil
+	}
+
+	regex, err := regexp.Compile(globToRegex(value))
+	if err != nil {
+		return pattern{}, fmt.Errorf("error compiling %q: %w", value, err)
+	}
+
+	return pattern{regex: regex, fullURL: false}, nil
+}
+
+// globToRegex converts the glob to an anchored regular expression.
+func globToRegex(glob string) string {
+	var builder strings.Builder
+
+	builder.WriteString("^")
+
+	for glob != "" {
+		switch {
+		case strings.HasPrefix(glob, "**"):
+			builder.WriteString(".*")
+
+			glob = glob[2:]
+		case strings.HasPrefix(glob, "*"):
+			builder.WriteString("[^/]*")
+
+			glob = glob[1:]
+		default:
+			end := strings.Index(glob, "*")
+			if end == -1 {
+				end = len(glob)
+			}
+
+			builder.WriteString(regexp.QuoteMeta(glob[:end]))
+
+			glob = glob[end:]
+		}
+	}
+
+	builder.WriteString("$")
+
+	return builder.String()
+}
+
+func (p pattern) match(u *url.URL) bool {
+	if p.fullURL {
+		return p.regex.MatchString(u.String())
+	}
+
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+
+	return p.regex.MatchString(path)
+}
@@ -0,0 +1,119 @@
+package filter_test
+
+import (
+	"net/url"
+	"testing"
+
+	"codeflow.dananglin.me.uk/apollo/web-crawler/internal/filter"
+)
+
+func TestAllowed(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		include []string
+		exclude []string
+		rawURL  string
+		want    bool
+	}{
+		{
+			name:   "No patterns",
+			rawURL: "https://example.com/admin",
+			want:   true,
+		},
+		{
+			name:    "Included by a recursive glob",
+			include: []string{"/docs/**"},
+			rawURL:  "https://example.com/docs/guides/install",
+			want:    true,
+		},
+		{
+			name:    "Not included by a recursive glob",
+			include: []string{"/docs/**"},
+			rawURL:  "https://example.com/blog/hello-world",
+			want:    false,
+		},
+		{
+			name:    "Single star glob does not match across segments",
+			include: []string{"/docs/*"},
+			rawURL:  "https://example.com/docs/guides/install",
+			want:    false,
+		},
+		{
+			name:    "Included by one of several patterns",
+			include: []string{"/docs/**", "/blog/*"},
+			rawURL:  "https://example.com/blog/hello-world",
+			want:    true,
+		},
+		{
+			name:    "Excluded by a glob",
+			exclude: []string{"/admin*"},
+			rawURL:  "https://example.com/admin",
+			want:    false,
+		},
+		{
+			name:    "Exclude takes precedence over include",
+			include: []string{"/**"},
+			exclude: []string{"/admin/**"},
+			rawURL:  "https://example.com/admin/users",
+			want:    false,
+		},
+		{
+			name:    "Glob matches the query string",
+			exclude: []string{"/**?*sort=*"},
+			rawURL:  "https://example.com/products?page=2&sort=price",
+			want:    false,
+		},
+		{
+			name:    "Excluded by a regular expression",
+			exclude: []string{`re:[?&]sort=`},
+			rawURL:  "https://example.com/products?sort=price",
+			want:    false,
+		},
+		{
+			name:    "Regular expression is matched against the full URL",
+			include: []string{`re:^https://example\.com/docs/`},
+			rawURL:  "https://example.com/docs/index.html",
+			want:    true,
+		},
+		{
+			name:    "Glob special characters are matched literally",
+			include: []string{"/files/report.pdf"},
+			rawURL:  "https://example.com/files/report-pdf",
+			want:    false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlFilter, err := filter.New(tc.include, tc.exclude)
+			if err != nil {
+				t.Fatalf("Test %q FAILED: unexpected error creating the filter: %v", tc.name, err)
+			}
+
+			parsedURL, err := url.Parse(tc.rawURL)
+			if err != nil {
+				t.Fatalf("Test %q FAILED: unexpected error parsing %q: %v", tc.name, tc.rawURL, err)
+			}
+
+			if got := urlFilter.Allowed(parsedURL); got != tc.want {
+				t.Errorf("Test %q FAILED: unexpected result for %s: want %t, got %t", tc.name, tc.rawURL, tc.want, got)
+			} else {
+				t.Logf("Test %q PASSED: expected result for %s: got %t", tc.name, tc.rawURL, got)
+			}
+		})
+	}
+}
+
+func TestNewInvalidPattern(t *testing.T) {
+	t.Parallel()
+
+	for _, pattern := range []string{"", "re:", "re:[a-z"} {
+		if _, err := filter.New(nil, []string{pattern}); err == nil {
+			t.Errorf("Test 'TestNewInvalidPattern' FAILED: expected an error for the pattern %q", pattern)
+		}
+	}
+}
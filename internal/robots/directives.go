@@ -0,0 +1,67 @@
+package robots
+
+import (
+	"slices"
+	"strings"
+)
+
+// Directives are the indexing directives of a page given in a robots meta tag
+// or in an X-Robots-Tag header.
+type Directives struct {
+	NoIndex  bool
+	NoFollow bool
+}
+
+// parameterisedDirectives are the directives that take a value after a colon.
+// They must not be mistaken for a user agent prefix.
+var parameterisedDirectives = []string{
+	"max-snippet",
+	"max-image-preview",
+	"max-video-preview",
+	"unavailable_after",
+}
+
+// ParseDirectives parses the comma separated directives of a robots meta tag or of an
+// X-Robots-Tag header. Directives that follow a user agent prefix (e.g. "googlebot: noindex")
+// only apply if the prefix matches the product token of the user agent. The "none"
+// directive is the same as "noindex, nofollow".
+func ParseDirectives(value, userAgent string) Directives {
+	var directives Directives
+
+	token := productToken(userAgent)
+	applies := true
+
+	for _, part := range strings.Split(value, ",") {
+		part = strings.ToLower(strings.TrimSpace(part))
+
+		if prefix, rest, ok := strings.Cut(part, ":"); ok && !slices.Contains(parameterisedDirectives, strings.TrimSpace(prefix)) {
+			prefix = strings.TrimSpace(prefix)
+			applies = prefix == token || prefix == "robots"
+			part = strings.TrimSpace(rest)
+		}
+
+		if !applies {
+			continue
+		}
+
+		switch part {
+		case "noindex":
+			directives.NoIndex = true
+		case "nofollow":
+			directives.NoFollow = true
+		case "none":
+			directives.NoIndex = true
+			directives.NoFollow = true
+		}
+	}
+
+	return directives
+}
+
+// Merge returns the directives that apply when both sets of directives are given.
+func (d Directives) Merge(other Directives) Directives {
+	return Directives{
+		NoIndex:  d.NoIndex || other.NoIndex,
+		NoFollow: d.NoFollow || other.NoFollow,
+	}
+}
@@ -0,0 +1,73 @@
+package robots_test
+
+import (
+	"slices"
+	"testing"
+
+	"codeflow.dananglin.me.uk/apollo/web-crawler/internal/robots"
+)
+
+func TestParseDirectives(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name  string
+		value string
+		want  robots.Directives
+	}{
+		{
+			name:  "No directives",
+			value: "",
+			want:  robots.Directives{NoIndex: false, NoFollow: false},
+		},
+		{
+			name:  "Noindex and nofollow",
+			value: "NOINDEX, nofollow",
+			want:  robots.Directives{NoIndex: true, NoFollow: true},
+		},
+		{
+			name:  "None",
+			value: "none",
+			want:  robots.Directives{NoIndex: true, NoFollow: true},
+		},
+		{
+			name:  "Directive with a value",
+			value: "unavailable_after: 2025-01-01, nofollow",
+			want:  robots.Directives{NoIndex: false, NoFollow: true},
+		},
+		{
+			name:  "Directives for another user agent",
+			value: "googlebot: noindex, nofollow",
+			want:  robots.Directives{NoIndex: false, NoFollow: false},
+		},
+		{
+			name:  "Directives for the crawler's user agent",
+			value: "noindex, web-crawler: nofollow",
+			want:  robots.Directives{NoIndex: true, NoFollow: true},
+		},
+	}
+
+	for ind, tc := range slices.All(cases) {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := robots.ParseDirectives(tc.value, "web-crawler/1.0")
+			if got != tc.want {
+				t.Errorf(
+					"Test %d - '%s' FAILED: unexpected directives: want %+v, got %+v",
+					ind+1,
+					tc.name,
+					tc.want,
+					got,
+				)
+			} else {
+				t.Logf(
+					"Test %d - '%s' PASSED: expected directives: got %+v",
+					ind+1,
+					tc.name,
+					got,
+				)
+			}
+		})
+	}
+}
@@ -0,0 +1,249 @@
+// Package robots parses robots.txt files as described in RFC 9309
+// (https://www.rfc-editor.org/rfc/rfc9309) and evaluates whether a
+// crawler is allowed to visit a given path.
+package robots
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rules are the robots.txt rules that apply to a single user agent.
+type Rules struct {
+	rules      []rule
+	crawlDelay time.Duration
+	sitemaps   []string
+}
+
+type rule struct {
+	allow   bool
+	pattern string
+}
+
+type group struct {
+	agents     []string
+	rules      []rule
+	crawlDelay time.Duration
+}
+
+// AllowAll returns rules that allow access to every path.
+func AllowAll() Rules {
+	return Rules{}
+}
+
+// DisallowAll returns rules that deny access to every path.
+func DisallowAll() Rules {
+	return Rules{
+		rules: []rule{{allow: false, pattern: "/"}},
+	}
+}
+
+// Parse parses the robots.txt document from the reader and returns the rules that apply
+// to the given user agent. The rules are taken from the groups that match the product
+// token of the user agent (e.g. "web-crawler" from "web-crawler/1.0"), or from the
+// wildcard ("*") group if no group matches.
+func Parse(reader io.Reader, userAgent string) (Rules, error) {
+	var (
+		groups   []group
+		sitemaps []string
+		current  *group
+		inAgents bool
+	)
+
+	scanner := bufio.NewScanner(reader)
+
+	for scanner.Scan() {
+		key, value, ok := parseLine(scanner.Text())
+		if !ok {
+			continue
+		}
+
+		switch key {
+		case "user-agent":
+			// Consecutive user-agent lines belong to the same group.
+			if !inAgents {
+				groups = append(groups, group{})
+				current = &groups[len(groups)-1]
+			}
+
+			current.agents = append(current.agents, strings.ToLower(value))
+			inAgents = true
+		case "allow", "disallow":
+			inAgents = false
+
+			// Rules outside of a group and empty disallow rules are ignored.
+			if current == nil || value == "" {
+				continue
+			}
+
+			current.rules = append(current.rules, rule{allow: key == "allow", pattern: value})
+		case "crawl-delay":
+			inAgents = false
+
+			if current == nil {
+				continue
+			}
+
+			seconds, err := strconv.ParseFloat(value, 64)
+			if err != nil || seconds < 0 {
+				continue
+			}
+
+			current.crawlDelay = time.Duration(seconds * float64(time.Second))
+		case "sitemap":
+			// Sitemap lines are independent of the user agent groups.
+			sitemaps = append(sitemaps, value)
+		default:
+			inAgents = false
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return Rules{}, fmt.Errorf("error reading the robots.txt document: %w", err)
+	}
+
+	rules := selectRules(groups, productToken(userAgent))
+	rules.sitemaps = sitemaps
+
+	return rules, nil
+}
+
+// parseLine splits a robots.txt line into its lowercased key and its value.
+// Comments and whitespace are removed.
+func parseLine(line string) (string, string, bool) {
+	if ind := strings.Index(line, "#"); ind >= 0 {
+		line = line[:ind]
+	}
+
+	key, value, found := strings.Cut(line, ":")
+	if !found {
+		return "", "", false
+	}
+
+	return strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value), true
+}
+
+// productToken returns the lowercased product token of the user agent
+// e.g. "web-crawler" for "Web-Crawler/1.0 (+https://example.org)".
+func productToken(userAgent string) string {
+	token, _, _ := strings.Cut(strings.TrimSpace(userAgent), "/")
+	token, _, _ = strings.Cut(token, " ")
+
+	return strings.ToLower(token)
+}
+
+// selectRules merges the rules of all the groups that match the product token.
+// The rules of the wildcard groups are used if no group matches.
+func selectRules(groups []group, token string) Rules {
+	var matched, wildcard Rules
+
+	foundMatch := false
+
+	for _, g := range slices.All(groups) {
+		switch {
+		case token != "" && slices.Contains(g.agents, token):
+			foundMatch = true
+			matched.rules = append(matched.rules, g.rules...)
+			matched.crawlDelay = max(matched.crawlDelay, g.crawlDelay)
+		case slices.Contains(g.agents, "*"):
+			wildcard.rules = append(wildcard.rules, g.rules...)
+			wildcard.crawlDelay = max(wildcard.crawlDelay, g.crawlDelay)
+		}
+	}
+
+	if foundMatch {
+		return matched
+	}
+
+	return wildcard
+}
+
+// Allowed evaluates to true if the rules allow access to the path.
+// The path should include the query string if there is one.
+// The longest matching rule wins and an allow rule wins over a disallow
+// rule of the same length. A path that does not match any rule is allowed.
+func (r Rules) Allowed(path string) bool {
+	if path == "" {
+		path = "/"
+	}
+
+	// The robots.txt file itself is always allowed.
+	if path == "/robots.txt" {
+		return true
+	}
+
+	allowed := true
+	longest := -1
+
+	for _, rl := range slices.All(r.rules) {
+		if !match(rl.pattern, path) {
+			continue
+		}
+
+		length := len(rl.pattern)
+
+		if length > longest || (length == longest && rl.allow) {
+			longest = length
+			allowed = rl.allow
+		}
+	}
+
+	return allowed
+}
+
+// CrawlDelay returns the minimum delay between requests specified by the
+// Crawl-delay directive. A zero value means that no delay was specified.
+func (r Rules) CrawlDelay() time.Duration {
+	return r.crawlDelay
+}
+
+// Sitemaps returns the URLs of the sitemaps listed in the robots.txt file.
+func (r Rules) Sitemaps() []string {
+	return r.sitemaps
+}
+
+// match evaluates to true if the path matches the pattern. The pattern
+// may contain the '*' wildcard which matches any sequence of characters,
+// and may end with '$' to anchor the pattern to the end of the path.
+func match(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	if anchored {
+		pattern = strings.TrimSuffix(pattern, "$")
+	}
+
+	parts := strings.Split(pattern, "*")
+
+	// The first part must match the start of the path.
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+
+	remaining := path[len(parts[0]):]
+
+	if len(parts) == 1 {
+		return !anchored || remaining == ""
+	}
+
+	// Match each of the middle parts at the earliest possible position.
+	for _, part := range slices.All(parts[1 : len(parts)-1]) {
+		ind := strings.Index(remaining, part)
+		if ind < 0 {
+			return false
+		}
+
+		remaining = remaining[ind+len(part):]
+	}
+
+	last := parts[len(parts)-1]
+
+	if anchored {
+		return strings.HasSuffix(remaining, last)
+	}
+
+	return strings.Contains(remaining, last)
+}
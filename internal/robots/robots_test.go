@@ -0,0 +1,231 @@
+package robots_test
+
+import (
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"codeflow.dananglin.me.uk/apollo/web-crawler/internal/robots"
+)
+
+func TestAllowed(t *testing.T) {
+	t.Parallel()
+
+	path := "testdata/robots.txt"
+
+	cases := []struct {
+		name      string
+		userAgent string
+		path      string
+		want      bool
+	}{
+		{
+			name:      "Wildcard group, path with no matching rules",
+			userAgent: "unknown-crawler/2.0",
+			path:      "/blog/hello-world",
+			want:      true,
+		},
+		{
+			name:      "Wildcard group, disallowed path",
+			userAgent: "unknown-crawler/2.0",
+			path:      "/admin/users",
+			want:      false,
+		},
+		{
+			name:      "Wildcard group, longest match allows the path",
+			userAgent: "unknown-crawler/2.0",
+			path:      "/private/public-page.html",
+			want:      true,
+		},
+		{
+			name:      "Wildcard group, longest match disallows the path",
+			userAgent: "unknown-crawler/2.0",
+			path:      "/private/secrets.html",
+			want:      false,
+		},
+		{
+			name:      "Wildcard group, wildcard pattern in the query",
+			userAgent: "unknown-crawler/2.0",
+			path:      "/products?sort=price&page=2",
+			want:      false,
+		},
+		{
+			name:      "Wildcard group, anchored pattern matches",
+			userAgent: "unknown-crawler/2.0",
+			path:      "/docs/manual.pdf",
+			want:      false,
+		},
+		{
+			name:      "Wildcard group, anchored pattern does not match",
+			userAgent: "unknown-crawler/2.0",
+			path:      "/docs/manual.pdf.html",
+			want:      true,
+		},
+		{
+			name:      "Named group, case insensitive product token",
+			userAgent: "web-crawler/1.0 (+https://example.org)",
+			path:      "/drafts/new-post",
+			want:      false,
+		},
+		{
+			name:      "Named group, rules from the wildcard group do not apply",
+			userAgent: "web-crawler/1.0",
+			path:      "/admin",
+			want:      true,
+		},
+		{
+			name:      "Named group, longest match allows the path",
+			userAgent: "web-crawler/1.0",
+			path:      "/drafts/published/first-post",
+			want:      true,
+		},
+		{
+			name:      "Named group, allow wins over disallow of equal length",
+			userAgent: "another-crawler",
+			path:      "/page.html",
+			want:      true,
+		},
+		{
+			name:      "Named group, wildcard pattern in the path",
+			userAgent: "another-crawler",
+			path:      "/scripts/index.php?id=1",
+			want:      false,
+		},
+		{
+			name:      "Blocked crawler",
+			userAgent: "blocked-crawler",
+			path:      "/",
+			want:      false,
+		},
+		{
+			name:      "Blocked crawler can still access robots.txt",
+			userAgent: "blocked-crawler",
+			path:      "/robots.txt",
+			want:      true,
+		},
+	}
+
+	for ind, tc := range slices.All(cases) {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			file, err := os.Open(path)
+			if err != nil {
+				t.Fatalf("Test %d - '%s' FAILED: unable to open %s: %v", ind+1, tc.name, path, err)
+			}
+			defer file.Close()
+
+			rules, err := robots.Parse(file, tc.userAgent)
+			if err != nil {
+				t.Fatalf("Test %d - '%s' FAILED: unexpected error: %v", ind+1, tc.name, err)
+			}
+
+			got := rules.Allowed(tc.path)
+			if got != tc.want {
+				t.Errorf(
+					"Test %d - '%s' FAILED: unexpected result for %s: want %t, got %t",
+					ind+1,
+					tc.name,
+					tc.path,
+					tc.want,
+					got,
+				)
+			} else {
+				t.Logf(
+					"Test %d - '%s' PASSED: expected result for %s: got %t",
+					ind+1,
+					tc.name,
+					tc.path,
+					got,
+				)
+			}
+		})
+	}
+}
+
+func TestCrawlDelayAndSitemaps(t *testing.T) {
+	t.Parallel()
+
+	path := "testdata/robots.txt"
+
+	cases := []struct {
+		name         string
+		userAgent    string
+		wantDelay    time.Duration
+		wantSitemaps []string
+	}{
+		{
+			name:      "Wildcard group",
+			userAgent: "unknown-crawler",
+			wantDelay: 2 * time.Second,
+			wantSitemaps: []string{
+				"https://example.org/sitemap.xml",
+				"https://example.org/sitemaps/posts.xml.gz",
+			},
+		},
+		{
+			name:      "Named group",
+			userAgent: "web-crawler",
+			wantDelay: 500 * time.Millisecond,
+			wantSitemaps: []string{
+				"https://example.org/sitemap.xml",
+				"https://example.org/sitemaps/posts.xml.gz",
+			},
+		},
+	}
+
+	for ind, tc := range slices.All(cases) {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			file, err := os.Open(path)
+			if err != nil {
+				t.Fatalf("Test %d - '%s' FAILED: unable to open %s: %v", ind+1, tc.name, path, err)
+			}
+			defer file.Close()
+
+			rules, err := robots.Parse(file, tc.userAgent)
+			if err != nil {
+				t.Fatalf("Test %d - '%s' FAILED: unexpected error: %v", ind+1, tc.name, err)
+			}
+
+			if got := rules.CrawlDelay(); got != tc.wantDelay {
+				t.Errorf(
+					"Test %d - '%s' FAILED: unexpected crawl delay: want %s, got %s",
+					ind+1,
+					tc.name,
+					tc.wantDelay,
+					got,
+				)
+			}
+
+			if got := rules.Sitemaps(); !reflect.DeepEqual(got, tc.wantSitemaps) {
+				t.Errorf(
+					"Test %d - '%s' FAILED: unexpected sitemaps: want %v, got %v",
+					ind+1,
+					tc.name,
+					tc.wantSitemaps,
+					got,
+				)
+			}
+		})
+	}
+}
+
+func TestEmptyRobotsTxt(t *testing.T) {
+	t.Parallel()
+
+	rules, err := robots.Parse(strings.NewReader(""), "web-crawler")
+	if err != nil {
+		t.Fatalf("Test 'TestEmptyRobotsTxt' FAILED: unexpected error: %v", err)
+	}
+
+	if !rules.Allowed("/any/path") {
+		t.Errorf("Test 'TestEmptyRobotsTxt' FAILED: the path was unexpectedly disallowed")
+	} else {
+		t.Logf("Test 'TestEmptyRobotsTxt' PASSED: the path was allowed")
+	}
+}
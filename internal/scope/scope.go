@@ -0,0 +1,151 @@
+// Package scope classifies the links found during a crawl as internal,
+// subdomain, scope host or external links based on the hosts of the seed URLs.
+package scope
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// Mode is the rule used to decide which hosts are in scope.
+type Mode string
+
+const (
+	// ModeHost only includes the hosts of the seed URLs.
+	ModeHost Mode = "host"
+
+	// ModeSubdomains includes the hosts of the seed URLs and all of their subdomains.
+	ModeSubdomains Mode = "subdomains"
+
+	// ModeDomain includes every host under the registrable domains of the seed URLs
+	// (e.g. example.co.uk for www.example.co.uk) as defined by the public suffix list.
+	ModeDomain Mode = "domain"
+
+	// ModeHosts includes the hosts of the seed URLs and an explicit list of hosts.
+	ModeHosts Mode = "hosts"
+)
+
+// LinkType is the classification of a link.
+type LinkType string
+
+const (
+	// Internal is a link to the host of one of the seed URLs.
+	Internal LinkType = "internal"
+
+	// Subdomain is a link to another host that is in scope.
+	Subdomain LinkType = "subdomain"
+
+	// ScopeHost is a link to one of the hosts that are explicitly
+	// listed in the hosts mode.
+	ScopeHost LinkType = "scope-host"
+
+	// External is a link to a host that is out of scope.
+	External LinkType = "external"
+)
+
+var (
+	errUnknownMode = errors.New("unknown scope mode")
+	errNoHosts     = errors.New("no hosts are provided for the hosts scope mode")
+)
+
+// Scope decides which hosts are in scope for a crawl.
+type Scope struct {
+	mode      Mode
+	seedHosts []string
+	domains   []string
+	hosts     []string
+}
+
+// New returns the scope of a crawl of the seed URLs. The hosts are only used in
+// the hosts mode. An empty mode is the same as ModeHost.
+func New(mode Mode, seedURLs []*url.URL, hosts []string) (Scope, error) {
+	seedHosts := make([]string, 0, len(seedURLs))
+
+	for _, seedURL := range slices.All(seedURLs) {
+		if host := strings.ToLower(seedURL.Hostname()); !slices.Contains(seedHosts, host) {
+			seedHosts = append(seedHosts, host)
+		}
+	}
+
+	scope := Scope{
+		mode:      mode,
+		seedHosts: seedHosts,
+		domains:   nil,
+		hosts:     nil,
+	}
+
+	switch mode {
+	case "":
+		scope.mode = ModeHost
+	case ModeHost, ModeSubdomains:
+	case ModeDomain:
+		// The domain of hosts without a registrable domain, such as IP addresses
+		// and localhost, is the host itself.
+		scope.domains = make([]string, 0, len(seedHosts))
+
+		for _, host := range slices.All(seedHosts) {
+			domain, err := publicsuffix.EffectiveTLDPlusOne(host)
+			if err != nil {
+				domain = host
+			}
+
+			scope.domains = append(scope.domains, domain)
+		}
+	case ModeHosts:
+		if len(hosts) == 0 {
+			return Scope{}, errNoHosts
+		}
+
+		scope.hosts = make([]string, 0, len(hosts))
+
+		for _, host := range slices.All(hosts) {
+			scope.hosts = append(scope.hosts, strings.ToLower(host))
+		}
+	default:
+		return Scope{}, fmt.Errorf("%w: %q", errUnknownMode, mode)
+	}
+
+	return scope, nil
+}
+
+// Classify returns the link type of the URL.
+func (s Scope) Classify(u *url.URL) LinkType {
+	host := strings.ToLower(u.Hostname())
+
+	if slices.Contains(s.seedHosts, host) {
+		return Internal
+	}
+
+	if host == "" {
+		return External
+	}
+
+	var inScope bool
+
+	switch s.mode {
+	case ModeSubdomains:
+		inScope = slices.ContainsFunc(s.seedHosts, func(seedHost string) bool {
+			return strings.HasSuffix(host, "."+seedHost)
+		})
+	case ModeDomain:
+		inScope = slices.ContainsFunc(s.domains, func(domain string) bool {
+			return host == domain || strings.HasSuffix(host, "."+domain)
+		})
+	case ModeHosts:
+		if slices.Contains(s.hosts, host) {
+			return ScopeHost
+		}
+	case ModeHost:
+	}
+
+	if inScope {
+		return Subdomain
+	}
+
+	return External
+}
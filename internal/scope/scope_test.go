@@ -0,0 +1,172 @@
+package scope_test
+
+import (
+	"net/url"
+	"testing"
+
+	"codeflow.dananglin.me.uk/apollo/web-crawler/internal/scope"
+)
+
+func TestClassify(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name   string
+		mode   scope.Mode
+		seeds  []string
+		hosts  []string
+		rawURL string
+		want   scope.LinkType
+	}{
+		{
+			name:   "Host mode, same host",
+			mode:   scope.ModeHost,
+			seeds:  []string{"https://example.com"},
+			rawURL: "https://EXAMPLE.com/about",
+			want:   scope.Internal,
+		},
+		{
+			name:   "Host mode, subdomain",
+			mode:   scope.ModeHost,
+			seeds:  []string{"https://example.com"},
+			rawURL: "https://docs.example.com",
+			want:   scope.External,
+		},
+		{
+			name:   "Default mode, different host",
+			mode:   "",
+			seeds:  []string{"https://example.com"},
+			rawURL: "https://www.example.com",
+			want:   scope.External,
+		},
+		{
+			name:   "Subdomains mode, subdomain",
+			mode:   scope.ModeSubdomains,
+			seeds:  []string{"https://example.com"},
+			rawURL: "https://docs.example.com/guide",
+			want:   scope.Subdomain,
+		},
+		{
+			name:   "Subdomains mode, parent domain",
+			mode:   scope.ModeSubdomains,
+			seeds:  []string{"https://www.example.com"},
+			rawURL: "https://example.com",
+			want:   scope.External,
+		},
+		{
+			name:   "Subdomains mode, host with the same suffix",
+			mode:   scope.ModeSubdomains,
+			seeds:  []string{"https://example.com"},
+			rawURL: "https://notexample.com",
+			want:   scope.External,
+		},
+		{
+			name:   "Domain mode, sibling host",
+			mode:   scope.ModeDomain,
+			seeds:  []string{"https://www.example.co.uk"},
+			rawURL: "https://blog.example.co.uk",
+			want:   scope.Subdomain,
+		},
+		{
+			name:   "Domain mode, registrable domain",
+			mode:   scope.ModeDomain,
+			seeds:  []string{"https://www.example.co.uk"},
+			rawURL: "https://example.co.uk",
+			want:   scope.Subdomain,
+		},
+		{
+			name:   "Domain mode, different domain under the same public suffix",
+			mode:   scope.ModeDomain,
+			seeds:  []string{"https://www.example.co.uk"},
+			rawURL: "https://www.other.co.uk",
+			want:   scope.External,
+		},
+		{
+			name:   "Domain mode, different project on a shared hosting suffix",
+			mode:   scope.ModeDomain,
+			seeds:  []string{"https://alice.github.io"},
+			rawURL: "https://bob.github.io",
+			want:   scope.External,
+		},
+		{
+			name:   "Hosts mode, listed host",
+			mode:   scope.ModeHosts,
+			seeds:  []string{"https://example.com"},
+			hosts:  []string{"shop.example.net", "Docs.Example.org"},
+			rawURL: "https://docs.example.org",
+			want:   scope.ScopeHost,
+		},
+		{
+			name:   "Hosts mode, unlisted host",
+			mode:   scope.ModeHosts,
+			seeds:  []string{"https://example.com"},
+			hosts:  []string{"shop.example.net"},
+			rawURL: "https://blog.example.com",
+			want:   scope.External,
+		},
+		{
+			name:   "Host mode, host of another seed",
+			mode:   scope.ModeHost,
+			seeds:  []string{"https://example.com", "https://Shop.Example.net/catalogue"},
+			rawURL: "https://shop.example.net/basket",
+			want:   scope.Internal,
+		},
+		{
+			name:   "Subdomains mode, subdomain of another seed",
+			mode:   scope.ModeSubdomains,
+			seeds:  []string{"https://example.com", "https://example.net"},
+			rawURL: "https://docs.example.net",
+			want:   scope.Subdomain,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			seedURLs := make([]*url.URL, 0, len(tc.seeds))
+
+			for _, seed := range tc.seeds {
+				seedURL, err := url.Parse(seed)
+				if err != nil {
+					t.Fatalf("Test %q FAILED: unexpected error parsing %q: %v", tc.name, seed, err)
+				}
+
+				seedURLs = append(seedURLs, seedURL)
+			}
+
+			linkScope, err := scope.New(tc.mode, seedURLs, tc.hosts)
+			if err != nil {
+				t.Fatalf("Test %q FAILED: unexpected error creating the scope: %v", tc.name, err)
+			}
+
+			parsedURL, err := url.Parse(tc.rawURL)
+			if err != nil {
+				t.Fatalf("Test %q FAILED: unexpected error parsing %q: %v", tc.name, tc.rawURL, err)
+			}
+
+			if got := linkScope.Classify(parsedURL); got != tc.want {
+				t.Errorf("Test %q FAILED: unexpected link type for %s: want %s, got %s", tc.name, tc.rawURL, tc.want, got)
+			} else {
+				t.Logf("Test %q PASSED: expected link type for %s: got %s", tc.name, tc.rawURL, got)
+			}
+		})
+	}
+}
+
+func TestNewInvalidScope(t *testing.T) {
+	t.Parallel()
+
+	baseURL, err := url.Parse("https://example.com")
+	if err != nil {
+		t.Fatalf("Test 'TestNewInvalidScope' FAILED: unexpected error parsing the base URL: %v", err)
+	}
+
+	if _, err := scope.New("everything", []*url.URL{baseURL}, nil); err == nil {
+		t.Error("Test 'TestNewInvalidScope' FAILED: expected an error for an unknown mode")
+	}
+
+	if _, err := scope.New(scope.ModeHosts, []*url.URL{baseURL}, nil); err == nil {
+		t.Error("Test 'TestNewInvalidScope' FAILED: expected an error for the hosts mode without hosts")
+	}
+}
@@ -0,0 +1,111 @@
+// Package sitemap parses sitemaps and sitemap indexes as described in
+// the sitemaps protocol (https://www.sitemaps.org/protocol.html).
+package sitemap
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Sitemap is the content of a parsed sitemap document. A sitemap contains
+// the URLs of a website's pages while a sitemap index contains the URLs
+// of other sitemaps.
+type Sitemap struct {
+	URLs     []string
+	Sitemaps []string
+}
+
+type document struct {
+	XMLName  xml.Name
+	URLs     []location `xml:"url"`
+	Sitemaps []location `xml:"sitemap"`
+}
+
+type location struct {
+	Loc string `xml:"loc"`
+}
+
+// gzipMagic is the header of a gzip compressed file.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// ErrTooLarge is returned when the uncompressed sitemap is larger than the maximum size.
+var ErrTooLarge = errors.New("the sitemap is larger than the maximum size")
+
+// Parse parses a sitemap or a sitemap index from the reader.
+// Gzip compressed documents are decompressed automatically. The maximum
+// size applies to the uncompressed document so that a small compressed
+// document cannot expand without limit.
+func Parse(reader io.Reader, maxSize int64) (Sitemap, error) {
+	bufReader := bufio.NewReader(reader)
+
+	header, err := bufReader.Peek(len(gzipMagic))
+	if err == nil && string(header) == string(gzipMagic) {
+		gzipReader, err := gzip.NewReader(bufReader)
+		if err != nil {
+			return Sitemap{}, fmt.Errorf("unable to decompress the sitemap: %w", err)
+		}
+		defer gzipReader.Close()
+
+		reader = gzipReader
+	} else {
+		reader = bufReader
+	}
+
+	var doc document
+
+	if err := xml.NewDecoder(&limitedReader{reader: reader, remaining: maxSize}).Decode(&doc); err != nil {
+		return Sitemap{}, fmt.Errorf("unable to decode the sitemap: %w", err)
+	}
+
+	switch doc.XMLName.Local {
+	case "urlset", "sitemapindex":
+	default:
+		return Sitemap{}, fmt.Errorf("unexpected root element: want urlset or sitemapindex, got %s", doc.XMLName.Local)
+	}
+
+	return Sitemap{
+		URLs:     locations(doc.URLs),
+		Sitemaps: locations(doc.Sitemaps),
+	}, nil
+}
+
+func locations(entries []location) []string {
+	output := make([]string, 0, len(entries))
+
+	for ind := range entries {
+		loc := strings.TrimSpace(entries[ind].Loc)
+		if loc != "" {
+			output = append(output, loc)
+		}
+	}
+
+	return output
+}
+
+// limitedReader reads from the reader until more than the remaining number
+// of bytes were read, after which it returns ErrTooLarge.
+type limitedReader struct {
+	reader    io.Reader
+	remaining int64
+}
+
+func (l *limitedReader) Read(data []byte) (int, error) {
+	// Read one more byte than remains to find out if the document is too large.
+	if int64(len(data)) > l.remaining+1 {
+		data = data[:l.remaining+1]
+	}
+
+	n, err := l.reader.Read(data)
+	l.remaining -= int64(n)
+
+	if l.remaining < 0 {
+		return n, ErrTooLarge
+	}
+
+	return n, err
+}
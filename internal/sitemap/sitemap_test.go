@@ -0,0 +1,157 @@
+package sitemap_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"codeflow.dananglin.me.uk/apollo/web-crawler/internal/sitemap"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		filepath string
+		gzipped  bool
+		want     sitemap.Sitemap
+	}{
+		{
+			name:     "Sitemap",
+			filepath: "testdata/sitemap.xml",
+			gzipped:  false,
+			want: sitemap.Sitemap{
+				URLs: []string{
+					"https://example.org/",
+					"https://example.org/posts/yet-another-web-crawler-has-emerged",
+					"https://example.org/about/contact",
+				},
+				Sitemaps: []string{},
+			},
+		},
+		{
+			name:     "Gzipped sitemap",
+			filepath: "testdata/sitemap.xml",
+			gzipped:  true,
+			want: sitemap.Sitemap{
+				URLs: []string{
+					"https://example.org/",
+					"https://example.org/posts/yet-another-web-crawler-has-emerged",
+					"https://example.org/about/contact",
+				},
+				Sitemaps: []string{},
+			},
+		},
+		{
+			name:     "Sitemap index",
+			filepath: "testdata/sitemapindex.xml",
+			gzipped:  false,
+			want: sitemap.Sitemap{
+				URLs: []string{},
+				Sitemaps: []string{
+					"https://example.org/sitemaps/posts.xml.gz",
+					"https://example.org/sitemaps/tags.xml",
+				},
+			},
+		},
+	}
+
+	for ind, tc := range slices.All(cases) {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			data, err := os.ReadFile(tc.filepath)
+			if err != nil {
+				t.Fatalf("Test %d - '%s' FAILED: unable to read %s: %v", ind+1, tc.name, tc.filepath, err)
+			}
+
+			if tc.gzipped {
+				var buf bytes.Buffer
+
+				writer := gzip.NewWriter(&buf)
+
+				if _, err := writer.Write(data); err != nil {
+					t.Fatalf("Test %d - '%s' FAILED: unable to compress the data: %v", ind+1, tc.name, err)
+				}
+
+				if err := writer.Close(); err != nil {
+					t.Fatalf("Test %d - '%s' FAILED: unable to compress the data: %v", ind+1, tc.name, err)
+				}
+
+				data = buf.Bytes()
+			}
+
+			got, err := sitemap.Parse(bytes.NewReader(data), 1024*1024)
+			if err != nil {
+				t.Fatalf("Test %d - '%s' FAILED: unexpected error: %v", ind+1, tc.name, err)
+			}
+
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf(
+					"Test %d - '%s' FAILED: unexpected sitemap parsed: want %v, got %v",
+					ind+1,
+					tc.name,
+					tc.want,
+					got,
+				)
+			} else {
+				t.Logf(
+					"Test %d - '%s' PASSED: expected sitemap parsed: got %v",
+					ind+1,
+					tc.name,
+					got,
+				)
+			}
+		})
+	}
+}
+
+func TestParseInvalidDocument(t *testing.T) {
+	t.Parallel()
+
+	if _, err := sitemap.Parse(strings.NewReader("<html><body>Not a sitemap</body></html>"), 1024); err == nil {
+		t.Errorf("Test 'TestParseInvalidDocument' FAILED: expected an error parsing an HTML document")
+	} else {
+		t.Logf("Test 'TestParseInvalidDocument' PASSED: expected error received: %v", err)
+	}
+}
+
+func TestParseTooLarge(t *testing.T) {
+	t.Parallel()
+
+	document := `<?xml version="1.0" encoding="UTF-8"?><urlset>` +
+		strings.Repeat("<url><loc>https://example.org/</loc></url>", 1000) + `</urlset>`
+
+	var compressed bytes.Buffer
+
+	writer := gzip.NewWriter(&compressed)
+
+	if _, err := writer.Write([]byte(document)); err != nil {
+		t.Fatalf("Test 'TestParseTooLarge' FAILED: unable to compress the data: %v", err)
+	}
+
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Test 'TestParseTooLarge' FAILED: unable to compress the data: %v", err)
+	}
+
+	// The compressed document is within the maximum size but the limit
+	// applies to the uncompressed document.
+	maxSize := int64(compressed.Len() * 2)
+
+	cases := map[string][]byte{
+		"Sitemap":         []byte(document),
+		"Gzipped sitemap": compressed.Bytes(),
+	}
+
+	for name, data := range cases {
+		if _, err := sitemap.Parse(bytes.NewReader(data), maxSize); !errors.Is(err, sitemap.ErrTooLarge) {
+			t.Errorf("Test 'TestParseTooLarge' FAILED: unexpected error parsing the %s: want %v, got %v", name, sitemap.ErrTooLarge, err)
+		}
+	}
+}
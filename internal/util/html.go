@@ -3,23 +3,49 @@ package util
 import (
 	"fmt"
 	"net/url"
+	"slices"
 	"strings"
 
 	"golang.org/x/net/html"
 )
 
+// Link is a link found in an HTML document. NoFollow is true if the link
+// is marked with rel="nofollow".
+type Link struct {
+	URL        string
+	AnchorText string
+	NoFollow   bool
+}
+
 func GetURLsFromHTML(htmlBody, rawBaseURL string) ([]string, error) {
+	links, err := GetLinksFromHTML(htmlBody, rawBaseURL)
+	if err != nil {
+		return []string{}, err
+	}
+
+	output := make([]string, len(links))
+
+	for ind := range slices.All(links) {
+		output[ind] = links[ind].URL
+	}
+
+	return output, nil
+}
+
+// GetLinksFromHTML returns the absolute URL and the anchor text of every link in the
+// HTML document. The anchor text is the text inside the link with the whitespace collapsed.
+func GetLinksFromHTML(htmlBody, rawBaseURL string) ([]Link, error) {
 	htmlDoc, err := html.Parse(strings.NewReader(htmlBody))
 	if err != nil {
-		return []string{}, fmt.Errorf("unable to parse the HTML document: %w", err)
+		return []Link{}, fmt.Errorf("unable to parse the HTML document: %w", err)
 	}
 
 	parsedRawBaseURL, err := url.Parse(rawBaseURL)
 	if err != nil {
-		return []string{}, fmt.Errorf("unable to parse the raw base URL %q: %w", rawBaseURL, err)
+		return []Link{}, fmt.Errorf("unable to parse the raw base URL %q: %w", rawBaseURL, err)
 	}
 
-	output := make([]string, 0, 3)
+	output := make([]Link, 0, 3)
 
 	var extractLinkFunc func(*html.Node) error
 
@@ -32,7 +58,11 @@ func GetURLsFromHTML(htmlBody, rawBaseURL string) ([]string, error) {
 						return fmt.Errorf("unable to get the absolute URL of %s: %w", a.Val, err)
 					}
 
-					output = append(output, extractedURL)
+					output = append(output, Link{
+						URL:        extractedURL,
+						AnchorText: anchorText(node),
+						NoFollow:   hasRel(node, "nofollow"),
+					})
 
 					break
 				}
@@ -49,12 +79,137 @@ func GetURLsFromHTML(htmlBody, rawBaseURL string) ([]string, error) {
 	}
 
 	if err := extractLinkFunc(htmlDoc); err != nil {
-		return []string{}, err
+		return []Link{}, err
 	}
 
 	return output, nil
 }
 
+// GetCanonicalURLFromHTML returns the absolute URL of the first <link rel="canonical">
+// element in the HTML document. An empty string is returned if the document does not
+// declare a canonical URL.
+func GetCanonicalURLFromHTML(htmlBody, rawPageURL string) (string, error) {
+	htmlDoc, err := html.Parse(strings.NewReader(htmlBody))
+	if err != nil {
+		return "", fmt.Errorf("unable to parse the HTML document: %w", err)
+	}
+
+	parsedPageURL, err := url.Parse(rawPageURL)
+	if err != nil {
+		return "", fmt.Errorf("unable to parse the page URL %q: %w", rawPageURL, err)
+	}
+
+	var findCanonicalFunc func(*html.Node) string
+
+	findCanonicalFunc = func(node *html.Node) string {
+		if node.Type == html.ElementNode && node.Data == "link" && hasRel(node, "canonical") {
+			for _, a := range node.Attr {
+				if a.Key == "href" && strings.TrimSpace(a.Val) != "" {
+					return strings.TrimSpace(a.Val)
+				}
+			}
+		}
+
+		for c := node.FirstChild; c != nil; c = c.NextSibling {
+			if href := findCanonicalFunc(c); href != "" {
+				return href
+			}
+		}
+
+		return ""
+	}
+
+	href := findCanonicalFunc(htmlDoc)
+	if href == "" {
+		return "", nil
+	}
+
+	canonicalURL, err := getAbsoluteURL(href, parsedPageURL)
+	if err != nil {
+		return "", fmt.Errorf("unable to get the absolute URL of %s: %w", href, err)
+	}
+
+	return canonicalURL, nil
+}
+
+// GetMetaRobotsFromHTML returns the content of the robots meta tags in the HTML
+// document joined with commas. An empty string is returned if there are none.
+func GetMetaRobotsFromHTML(htmlBody string) (string, error) {
+	htmlDoc, err := html.Parse(strings.NewReader(htmlBody))
+	if err != nil {
+		return "", fmt.Errorf("unable to parse the HTML document: %w", err)
+	}
+
+	contents := make([]string, 0)
+
+	var extractContentFunc func(*html.Node)
+
+	extractContentFunc = func(node *html.Node) {
+		if node.Type == html.ElementNode && node.Data == "meta" && strings.EqualFold(attribute(node, "name"), "robots") {
+			contents = append(contents, attribute(node, "content"))
+		}
+
+		for c := node.FirstChild; c != nil; c = c.NextSibling {
+			extractContentFunc(c)
+		}
+	}
+
+	extractContentFunc(htmlDoc)
+
+	return strings.Join(contents, ","), nil
+}
+
+// attribute returns the value of the node's attribute or an empty string
+// if the node does not have the attribute.
+func attribute(node *html.Node, key string) string {
+	for _, a := range node.Attr {
+		if a.Key == key {
+			return strings.TrimSpace(a.Val)
+		}
+	}
+
+	return ""
+}
+
+// hasRel evaluates to true if the rel attribute of the node contains the given
+// link type. Link types are case-insensitive and separated by whitespace.
+func hasRel(node *html.Node, linkType string) bool {
+	for _, a := range node.Attr {
+		if a.Key != "rel" {
+			continue
+		}
+
+		for _, value := range strings.Fields(a.Val) {
+			if strings.EqualFold(value, linkType) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// anchorText returns the text inside the node with the whitespace collapsed.
+func anchorText(node *html.Node) string {
+	var builder strings.Builder
+
+	var extractTextFunc func(*html.Node)
+
+	extractTextFunc = func(node *html.Node) {
+		if node.Type == html.TextNode {
+			builder.WriteString(node.Data + " ")
+		}
+
+		for c := node.FirstChild; c != nil; c = c.NextSibling {
+			extractTextFunc(c)
+		}
+	}
+
+	extractTextFunc(node)
+
+	return strings.Join(strings.Fields(builder.String()), " ")
+}
+
 func getAbsoluteURL(inputURL string, baseURL *url.URL) (string, error) {
 	parsedURL, err := url.Parse(inputURL)
 	if err != nil {
@@ -97,3 +97,106 @@ func testGetURLsFromHTML(path, baseURL string, want []string) func(t *testing.T)
 		}
 	}
 }
+
+func TestGetLinksFromHTML(t *testing.T) {
+	t.Parallel()
+
+	path := "testdata/GetURLFromHTML/blog.boot.dev.html"
+
+	htmlDoc, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Test TestGetLinksFromHTML FAILED: unable to open read data from %s: %v", path, err)
+	}
+
+	want := []util.Link{
+		{URL: "https://blog.boot.dev/path/one", AnchorText: "Boot.dev", NoFollow: false},
+		{URL: "https://other.com/path/one", AnchorText: "Boot.dev", NoFollow: false},
+	}
+
+	got, err := util.GetLinksFromHTML(string(htmlDoc), "https://blog.boot.dev")
+	if err != nil {
+		t.Fatalf("Test TestGetLinksFromHTML FAILED: unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("Test TestGetLinksFromHTML FAILED: unexpected links found in HTML body: want %v, got %v", want, got)
+	} else {
+		t.Logf("Test TestGetLinksFromHTML PASSED: expected links found in HTML body: got %v", got)
+	}
+}
+
+func TestGetCanonicalURLFromHTML(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		htmlBody string
+		want     string
+	}{
+		{
+			name:     "Absolute canonical URL",
+			htmlBody: `<html><head><link rel="canonical" href="https://example.com/posts/first"></head><body></body></html>`,
+			want:     "https://example.com/posts/first",
+		},
+		{
+			name:     "Relative canonical URL with several link types",
+			htmlBody: `<html><head><link rel="alternate"><link rel="Canonical preload" href="/posts/first"></head></html>`,
+			want:     "https://example.com/posts/first",
+		},
+		{
+			name:     "No canonical URL",
+			htmlBody: `<html><head><link rel="stylesheet" href="/style.css"></head><body></body></html>`,
+			want:     "",
+		},
+	}
+
+	for ind, tc := range slices.All(cases) {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := util.GetCanonicalURLFromHTML(tc.htmlBody, "https://example.com/posts/first?utm_source=feed")
+			if err != nil {
+				t.Fatalf("Test %d - '%s' FAILED: unexpected error: %v", ind+1, tc.name, err)
+			}
+
+			if got != tc.want {
+				t.Errorf("Test %d - '%s' FAILED: unexpected canonical URL: want %q, got %q", ind+1, tc.name, tc.want, got)
+			} else {
+				t.Logf("Test %d - '%s' PASSED: expected canonical URL: got %q", ind+1, tc.name, got)
+			}
+		})
+	}
+}
+
+func TestRobotsDirectivesInHTML(t *testing.T) {
+	t.Parallel()
+
+	htmlBody := `<html><head>` +
+		`<meta name="Robots" content="noindex"><meta name="description" content="nofollow"><meta name="robots" content="nofollow">` +
+		`</head><body><a href="/about">About</a><a href="/ads" rel="sponsored NOFOLLOW">Ads</a></body></html>`
+
+	wantLinks := []util.Link{
+		{URL: "https://example.com/about", AnchorText: "About", NoFollow: false},
+		{URL: "https://example.com/ads", AnchorText: "Ads", NoFollow: true},
+	}
+
+	gotLinks, err := util.GetLinksFromHTML(htmlBody, "https://example.com")
+	if err != nil {
+		t.Fatalf("Test TestRobotsDirectivesInHTML FAILED: unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(wantLinks, gotLinks) {
+		t.Errorf("Test TestRobotsDirectivesInHTML FAILED: unexpected links found in HTML body: want %v, got %v", wantLinks, gotLinks)
+	}
+
+	gotContent, err := util.GetMetaRobotsFromHTML(htmlBody)
+	if err != nil {
+		t.Fatalf("Test TestRobotsDirectivesInHTML FAILED: unexpected error: %v", err)
+	}
+
+	if want := "noindex,nofollow"; gotContent != want {
+		t.Errorf("Test TestRobotsDirectivesInHTML FAILED: unexpected robots meta tag content: want %q, got %q", want, gotContent)
+	} else {
+		t.Logf("Test TestRobotsDirectivesInHTML PASSED: expected robots meta tag content: got %q", gotContent)
+	}
+}
@@ -1,12 +1,24 @@
 package main
 
 import (
+	"bufio"
+	"context"
 	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
-	"codeflow.dananglin.me.uk/apollo/web-crawler/internal/crawler"
+	"codeflow.dananglin.me.uk/apollo/web-crawler/crawler"
 )
 
 func main() {
@@ -17,41 +29,396 @@ func main() {
 	}
 }
 
-var errNoURLProvided = errors.New("the URL is not provided")
+var (
+	errNoURLProvided = errors.New("no seed URLs are provided")
+	errReportFormat  = errors.New("unknown report format")
+	errLogLevel      = errors.New("unknown log level")
+	errLogFormat     = errors.New("unknown log format")
+)
 
 func run() error {
 	var (
-		maxWorkers int
-		maxPages   int
-		format     string
-		file       string
+		maxWorkers    int
+		maxPages      int
+		maxExternal   int
+		maxDuration   time.Duration
+		maxErrors     int
+		maxBytes      int64
+		maxDepth      int
+		maxRedirects  int
+		timeout       time.Duration
+		scopeMode     string
+		scopeHosts    stringList
+		userAgent     string
+		ignoreRobots  bool
+		nofollow      bool
+		include       stringList
+		exclude       stringList
+		rateLimit     float64
+		minDelay      time.Duration
+		maxAttempts   int
+		retryDelay    time.Duration
+		maxRetryDelay time.Duration
+		useSitemaps   bool
+		checkExternal bool
+		extWorkers    int
+		stateFile     string
+		storeFile     string
+		resume        bool
+		checkpoint    time.Duration
+		format        string
+		showReferrers bool
+		seedsFile     string
+		perSeed       bool
+		logLevel      string
+		logFormat     string
+		progress      bool
+		progressEvery time.Duration
+		file          string
 	)
 
 	flag.IntVar(&maxWorkers, "max-workers", 2, "The maximum number of concurrent workers")
-	flag.IntVar(&maxPages, "max-pages", 10, "The maximum number of pages to discover before stopping the crawl")
+	flag.IntVar(&maxPages, "max-pages", 10, "The maximum number of internal pages to fetch before stopping the crawl (0 means no limit)")
+	flag.IntVar(&maxExternal, "max-external-links", 0, "The maximum number of unique external links to record (0 means no limit)")
+	flag.DurationVar(&maxDuration, "max-duration", 0, "The maximum duration of the crawl, e.g. 30m (0 means no limit)")
+	flag.IntVar(
+		&maxErrors,
+		"max-errors",
+		0,
+		"The maximum number of pages that fail to be fetched before stopping the crawl (0 means no limit)",
+	)
+	flag.Int64Var(
+		&maxBytes,
+		"max-bytes",
+		0,
+		"The maximum total size in bytes of the fetched pages before stopping the crawl, "+
+			"not counting the robots.txt files, the sitemaps and the external links (0 means no limit)",
+	)
+	flag.IntVar(&maxDepth, "max-depth", 0, "The maximum number of clicks away from the base URL to follow links to (0 means no limit)")
+	flag.IntVar(&maxRedirects, "max-redirects", 10, "The maximum number of redirects to follow when fetching a page")
+	flag.DurationVar(&timeout, "timeout", crawler.DefaultTimeout, "The time limit of every request, including reading the response")
+	flag.StringVar(&scopeMode, "scope", "host", "The hosts to crawl. Valid modes are 'host', 'subdomains', 'domain' and 'hosts'")
+	flag.Var(&scopeHosts, "scope-host", "A host to crawl in addition to the base URL's host when the scope is 'hosts' (can be repeated)")
+	flag.StringVar(&userAgent, "user-agent", "web-crawler", "The user agent to send with each request and to match the rules in robots.txt")
+	flag.BoolVar(&ignoreRobots, "ignore-robots", false, "Set to true to ignore the rules in the robots.txt files")
+	flag.BoolVar(&nofollow, "respect-nofollow", false, "Set to true to not follow nofollow links and the links on nofollow pages")
+	flag.Var(&include, "include", "A pattern of the internal URLs to crawl; other internal URLs are excluded (can be repeated)")
+	flag.Var(&exclude, "exclude", "A pattern of the internal URLs to exclude from the crawl (can be repeated)")
+	flag.Float64Var(&rateLimit, "rate-limit", 0, "The maximum number of requests per second to send to each host (0 means no limit)")
+	flag.DurationVar(&minDelay, "min-delay", 0, "The minimum delay between consecutive requests to the same host (e.g. 500ms)")
+	flag.IntVar(&maxAttempts, "max-attempts", 3, "The maximum number of attempts to retrieve a page that fails with a transient error")
+	flag.DurationVar(&retryDelay, "retry-delay", 500*time.Millisecond, "The delay before the first retry, doubled after every failed attempt")
+	flag.DurationVar(&maxRetryDelay, "max-retry-delay", 30*time.Second, "The maximum delay between two attempts to retrieve a page")
+	flag.BoolVar(&useSitemaps, "sitemaps", false, "Set to true to also crawl the pages in the site's sitemaps and report the orphan pages")
+	flag.BoolVar(&checkExternal, "check-external", false, "Set to true to check the external links and report the broken ones")
+	flag.IntVar(&extWorkers, "external-workers", 1, "The maximum number of concurrent workers that check the external links")
+	flag.StringVar(&stateFile, "state-file", "", "The file to periodically save the state of the crawl to so that it can be resumed later")
+	flag.StringVar(&storeFile, "store-file", "", "The database file to store the pages in during the crawl instead of keeping them in memory")
+	flag.BoolVar(&resume, "resume", false, "Set to true to resume the crawl from the state saved in the state file")
+	flag.DurationVar(&checkpoint, "checkpoint-interval", 30*time.Second, "The interval between saves of the state of the crawl")
 	flag.StringVar(&format, "format", "text", "The format of the report. Valid formats are 'text', 'json' and 'csv'")
+	flag.BoolVar(&showReferrers, "show-referrers", false, "Set to true to list the pages that link to each page in the text and CSV reports")
+	flag.StringVar(&seedsFile, "seeds-file", "", "The file to read additional seed URLs from, one per line ('-' reads from stdin)")
+	flag.BoolVar(&perSeed, "report-per-seed", false, "Set to true to generate a separate report for every seed URL")
+	flag.StringVar(
+		&logLevel,
+		"log-level",
+		"info",
+		"The minimum level of the logged events. Valid levels are 'debug', 'info', 'warn' and 'error'",
+	)
+	flag.StringVar(&logFormat, "log-format", "text", "The format of the logs written to stderr. Valid formats are 'text' and 'json'")
+	flag.BoolVar(&progress, "progress", false, "Set to true to show the progress of the crawl")
+	flag.DurationVar(&progressEvery, "progress-interval", 10*time.Second, "The interval between progress lines when stderr is not a terminal")
 	flag.StringVar(&file, "file", "", "The file to save the report to")
 
 	flag.Parse()
 
-	if flag.NArg() < 1 {
+	seeds := flag.Args()
+
+	if seedsFile != "" {
+		fileSeeds, err := readSeeds(seedsFile)
+		if err != nil {
+			return fmt.Errorf("unable to read the seed URLs: %w", err)
+		}
+
+		seeds = append(seeds, fileSeeds...)
+	}
+
+	if len(seeds) == 0 {
 		return errNoURLProvided
 	}
 
-	baseURL := flag.Arg(0)
+	if format != "text" && format != "json" && format != "csv" {
+		return fmt.Errorf("%w: %q", errReportFormat, format)
+	}
+
+	logger, err := newLogger(os.Stderr, logLevel, logFormat)
+	if err != nil {
+		return fmt.Errorf("unable to create the logger: %w", err)
+	}
 
-	c, err := crawler.NewCrawler(baseURL, maxWorkers, maxPages, format, file)
+	c, err := crawler.New(
+		seeds,
+		crawler.WithMaxWorkers(maxWorkers),
+		crawler.WithExternalWorkers(extWorkers),
+		crawler.WithMaxPages(maxPages),
+		crawler.WithMaxExternalLinks(maxExternal),
+		crawler.WithMaxDuration(maxDuration),
+		crawler.WithMaxErrors(maxErrors),
+		crawler.WithMaxBytes(maxBytes),
+		crawler.WithMaxDepth(maxDepth),
+		crawler.WithMaxRedirects(maxRedirects),
+		crawler.WithScope(scopeMode, scopeHosts...),
+		crawler.WithUserAgent(userAgent),
+		crawler.WithIgnoreRobots(ignoreRobots),
+		crawler.WithRespectNofollow(nofollow),
+		crawler.WithInclude(include...),
+		crawler.WithExclude(exclude...),
+		crawler.WithRateLimit(rateLimit, minDelay),
+		crawler.WithRetries(maxAttempts, retryDelay, maxRetryDelay),
+		crawler.WithStateFile(stateFile, checkpoint),
+		crawler.WithResume(resume),
+		crawler.WithStoreFile(storeFile),
+		crawler.WithSitemaps(useSitemaps),
+		crawler.WithExternalLinkCheck(checkExternal),
+		crawler.WithLogger(logger),
+		crawler.WithFetcher(crawler.NewHTTPFetcher(crawler.NewHTTPClient(timeout))),
+	)
 	if err != nil {
 		return fmt.Errorf("unable to create the crawler: %w", err)
 	}
 
-	go c.Crawl(baseURL)
+	defer func() {
+		if err := c.Close(); err != nil {
+			logger.Warn("unable to close the crawler", slog.Any("error", err))
+		}
+	}()
+
+	// Cancel the crawl on SIGINT or SIGTERM so that a report can still be
+	// generated from the pages gathered so far.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Restore the default signal behaviour once the first signal is received so
+	// that a second one terminates the application immediately.
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	// The progress is written to stderr so that it never mixes with a report
+	// printed to stdout. It is shown on a single line that is updated in place
+	// when stderr is a terminal, otherwise a new line is printed every interval.
+	progressCtx, stopProgress := context.WithCancel(context.Background())
+	progressDone := make(chan struct{})
+
+	if progress {
+		go func() {
+			defer close(progressDone)
 
-	c.Wait()
+			if isTerminal(os.Stderr) {
+				c.RunProgress(progressCtx, os.Stderr, 250*time.Millisecond, true)
+			} else {
+				c.RunProgress(progressCtx, os.Stderr, progressEvery, false)
+			}
+		}()
+	} else {
+		close(progressDone)
+	}
+
+	result, err := c.Run(ctx)
+
+	// The final progress is printed before the report.
+	stopProgress()
+	<-progressDone
+
+	// The result is empty if the crawl could not be started. Otherwise the
+	// report of the pages gathered so far is written before the error is
+	// returned, for example when the final state of the crawl could not be saved.
+	if err != nil && len(result.Seeds) == 0 {
+		return fmt.Errorf("error running the crawl: %w", err)
+	}
 
-	if err := c.GenerateReport(); err != nil {
+	reportErr := writeReports(logger, c, result.Seeds, perSeed, format, showReferrers, file)
+
+	if err != nil {
+		return errors.Join(fmt.Errorf("error running the crawl: %w", err), reportErr)
+	}
+
+	return reportErr
+}
+
+// writeReports writes the report of the crawl, or a report for every seed URL if
+// perSeed is true, to the file at the given path or prints it to the screen.
+func writeReports(
+	logger *slog.Logger,
+	c *crawler.Crawler,
+	seeds []string,
+	perSeed bool,
+	format string,
+	showReferrers bool,
+	path string,
+) error {
+	if !perSeed {
+		return writeReport(logger, c, "", format, showReferrers, path)
+	}
+
+	paths := seedReportPaths(path, seeds)
+
+	for ind, seed := range slices.All(seeds) {
+		if err := writeReport(logger, c, seed, format, showReferrers, paths[ind]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeReport writes the report of the crawl, or of the seed URL if it is not
+// empty, to the file at the given path or prints it to the screen if the path
+// is empty. The report is read from the crawler's page store as it is written.
+func writeReport(logger *slog.Logger, c *crawler.Crawler, seed, format string, showReferrers bool, path string) error {
+	if path == "" {
+		if err := c.WriteReport(os.Stdout, seed, format, showReferrers); err != nil {
+			return fmt.Errorf("unable to generate the report: %w", err)
+		}
+
+		return nil
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("unable to create %s: %w", path, err)
+	}
+	defer file.Close()
+
+	if err := c.WriteReport(file, seed, format, showReferrers); err != nil {
 		return fmt.Errorf("unable to generate the report: %w", err)
 	}
 
+	logger.Info("report saved", slog.String("file", path))
+
+	return nil
+}
+
+// seedReportPaths returns the path of the report of every seed URL. The host of the
+// seed is added to the name of the file at the given path, followed by a number if
+// more than one seed URL has the same host. The reports are printed to the screen
+// if the path is empty.
+func seedReportPaths(path string, seeds []string) []string {
+	paths := make([]string, len(seeds))
+
+	if path == "" {
+		return paths
+	}
+
+	ext := filepath.Ext(path)
+	hosts := make(map[string]int)
+
+	for ind := range slices.All(seeds) {
+		name := seeds[ind]
+		if parsedURL, err := url.Parse(seeds[ind]); err == nil {
+			name = parsedURL.Hostname()
+		}
+
+		hosts[name]++
+		if hosts[name] > 1 {
+			name += "-" + strconv.Itoa(hosts[name])
+		}
+
+		paths[ind] = strings.TrimSuffix(path, ext) + "-" + name + ext
+	}
+
+	return paths
+}
+
+// readSeeds reads the seed URLs from the file at the given path, or from stdin
+// if the path is "-". Every non-empty line that is not a comment is a seed URL.
+func readSeeds(path string) ([]string, error) {
+	var reader io.Reader = os.Stdin
+
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("error opening %s: %w", path, err)
+		}
+		defer file.Close()
+
+		reader = file
+	}
+
+	seeds := make([]string, 0)
+	scanner := bufio.NewScanner(reader)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		seeds = append(seeds, line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading the seed URLs from %s: %w", path, err)
+	}
+
+	return seeds, nil
+}
+
+// newLogger returns a logger that writes the events at or above the
+// given level to the writer in the given format.
+func newLogger(writer io.Writer, level, format string) (*slog.Logger, error) {
+	var logLevel slog.Level
+
+	switch level {
+	case "debug":
+		logLevel = slog.LevelDebug
+	case "info":
+		logLevel = slog.LevelInfo
+	case "warn":
+		logLevel = slog.LevelWarn
+	case "error":
+		logLevel = slog.LevelError
+	default:
+		return nil, fmt.Errorf("%w: %q", errLogLevel, level)
+	}
+
+	options := slog.HandlerOptions{
+		AddSource:   false,
+		Level:       logLevel,
+		ReplaceAttr: nil,
+	}
+
+	switch format {
+	case "text":
+		return slog.New(slog.NewTextHandler(writer, &options)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(writer, &options)), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", errLogFormat, format)
+	}
+}
+
+// isTerminal evaluates to true if the file is a terminal.
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// stringList is a flag that can be repeated to build a list of values.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+
 	return nil
 }